@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/AlexThuku/GitCommitAI-/internal/ai"
+	"github.com/AlexThuku/GitCommitAI-/internal/config"
+	"github.com/AlexThuku/GitCommitAI-/internal/git"
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/slog"
+)
+
+// defaultSignKey marks -S given without a key id
+const defaultSignKey = "default"
+
+// newCommitCmd creates the commit command
+func newCommitCmd(cfg *config.Config, provider *ai.ChainProvider) *cobra.Command {
+	var (
+		timeout    time.Duration
+		candidates int
+		mode       interaction
+		opts       git.CommitOptions
+	)
+	commitCmd := &cobra.Command{
+		Use:   "commit",
+		Short: "Generate a commit message and commit the staged changes",
+		Args:  cobra.NoArgs,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			opts.Sign = cmd.Flags().Changed("gpg-sign")
+			if err := validateStyle(cfg.MessageStyle); err != nil {
+				return err
+			}
+			return mode.validate()
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			// Only staged changes are committed, so only they are described.
+			// When amending, the message covers the whole amended commit.
+			var (
+				diff string
+				err  error
+			)
+			if opts.Amend {
+				diff, err = git.AmendDiff()
+			} else {
+				diff, err = git.StagedDiff()
+			}
+			if err != nil {
+				slog.Error("Failed to get git diff", "error", err)
+				os.Exit(1)
+			}
+
+			if diff == "" {
+				if opts.Amend {
+					fmt.Fprintln(status, "The amended commit would have no changes.")
+				} else {
+					fmt.Fprintln(status, "No staged changes. Stage your changes first.")
+				}
+				os.Exit(exitNoChanges)
+			}
+
+			plan, err := planPrompt(cfg, diff)
+			if err != nil {
+				slog.Error("Failed to build prompt", "error", err)
+				os.Exit(1)
+			}
+
+			messages, more := generateMessages(cmd.Context(), cfg, provider, plan, timeout, candidates, &mode)
+
+			approved, finalMessage := chooseMessage(plan, messages, more, &mode)
+			if !approved {
+				fmt.Fprintln(status, "Operation cancelled.")
+				os.Exit(exitRejected)
+			}
+
+			if opts.SignKey == defaultSignKey {
+				opts.SignKey = ""
+			}
+			if err := git.Commit(plan.ensureTicket(finalMessage), opts); err != nil {
+				// Git's own output, e.g. from a failing hook, explains why
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	flags := commitCmd.Flags()
+	flags.DurationVar(&timeout, "timeout", cfg.Timeout, "maximum time to wait for the model (0 disables)")
+	flags.StringVar(&cfg.MessageStyle, "style", cfg.MessageStyle, "message depth: \"subject\" or \"full\" (body and footers)")
+	flags.IntVar(&candidates, "candidates", 1, "number of alternative messages to generate")
+	mode.addFlags(commitCmd, false)
+	flags.BoolVarP(&opts.Signoff, "signoff", "s", false, "add a Signed-off-by trailer")
+	flags.BoolVarP(&opts.NoVerify, "no-verify", "n", false, "bypass the pre-commit and commit-msg hooks")
+	flags.BoolVar(&opts.Amend, "amend", false, "replace the tip of the current branch")
+	flags.StringVar(&opts.Author, "author", "", "override the commit author (\"Name <email>\")")
+	flags.StringVarP(&opts.SignKey, "gpg-sign", "S", "", "GPG-sign the commit, optionally with the given key id")
+	flags.Lookup("gpg-sign").NoOptDefVal = defaultSignKey
+
+	return commitCmd
+}
@@ -0,0 +1,782 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/AlexThuku/GitCommitAI-/internal/ai"
+	"github.com/AlexThuku/GitCommitAI-/internal/budget"
+	"github.com/AlexThuku/GitCommitAI-/internal/cli"
+	"github.com/AlexThuku/GitCommitAI-/internal/config"
+	"github.com/AlexThuku/GitCommitAI-/internal/filter"
+	"github.com/AlexThuku/GitCommitAI-/internal/git"
+	"github.com/AlexThuku/GitCommitAI-/internal/prompt"
+	"github.com/AlexThuku/GitCommitAI-/internal/redact"
+	"github.com/AlexThuku/GitCommitAI-/internal/scope"
+	"github.com/AlexThuku/GitCommitAI-/pkg/commit"
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/slog"
+)
+
+// newGenerateCmd creates the generate command
+func newGenerateCmd(cfg *config.Config, provider *ai.ChainProvider) *cobra.Command {
+	var (
+		timeout    time.Duration
+		showDiff   bool
+		candidates int
+		mode       interaction
+	)
+	generateCmd := &cobra.Command{
+		Use:   "generate",
+		Short: "Generate a commit message",
+		Args:  cobra.NoArgs,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := validateStyle(cfg.MessageStyle); err != nil {
+				return err
+			}
+			return mode.validate()
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			// Get git diff
+			diff, err := git.GetDiff()
+			if err != nil {
+				slog.Error("Failed to get git diff", "error", err)
+				os.Exit(1)
+			}
+
+			if diff == "" {
+				fmt.Fprintln(status, "No changes detected. Stage your changes first.")
+				os.Exit(exitNoChanges)
+			}
+
+			plan, err := planPrompt(cfg, diff)
+			if err != nil {
+				slog.Error("Failed to build prompt", "error", err)
+				os.Exit(1)
+			}
+
+			if showDiff {
+				for _, line := range plan.redactions.Lines() {
+					fmt.Fprintf(os.Stderr, "Redacted: %s\n", line)
+				}
+				if err := plan.show(os.Stdout); err != nil {
+					slog.Error("Failed to build prompt", "error", err)
+					os.Exit(1)
+				}
+				return
+			}
+
+			messages, more := generateMessages(cmd.Context(), cfg, provider, plan, timeout, candidates, &mode)
+
+			// Present to user for approval
+			approved, finalMessage := chooseMessage(plan, messages, more, &mode)
+			if !approved {
+				fmt.Fprintln(status, "Operation cancelled.")
+				os.Exit(exitRejected)
+			}
+			out := plan.ensureTicket(finalMessage)
+			if mode.format == formatJSON {
+				if out, err = plan.report(provider, out, messages); err != nil {
+					slog.Error("Failed to write commit message", "error", err)
+					os.Exit(1)
+				}
+			}
+			if err := mode.writeMessage(out, git.SetCommitMessage); err != nil {
+				slog.Error("Failed to write commit message", "error", err)
+				os.Exit(1)
+			}
+			switch {
+			case mode.output != "":
+				fmt.Fprintf(status, "Commit message written to %s\n", mode.output)
+			case !mode.print:
+				fmt.Fprintln(status, "Commit message set successfully!")
+			}
+		},
+	}
+
+	generateCmd.Flags().DurationVar(&timeout, "timeout", cfg.Timeout, "maximum time to wait for the model (0 disables)")
+	generateCmd.Flags().BoolVar(&showDiff, "show-diff", false, "print exactly what would be sent to the model and exit")
+	generateCmd.Flags().StringVar(&cfg.MessageStyle, "style", cfg.MessageStyle, "message depth: \"subject\" or \"full\" (body and footers)")
+	generateCmd.Flags().IntVar(&candidates, "candidates", 1, "number of alternative messages to generate")
+	mode.addFlags(generateCmd, true)
+
+	return generateCmd
+}
+
+// generateMessages asks provider for up to n commit messages for plan,
+// exiting the process if generation fails, is interrupted or times out. The
+// returned function asks for more messages from the same prompt.
+func generateMessages(parent context.Context, cfg *config.Config, provider *ai.ChainProvider, plan *promptPlan, timeout time.Duration, n int, mode *interaction) ([]string, func() ([]string, error)) {
+	if !checkRedactions(cfg, plan.redactions, mode.interactive()) {
+		os.Exit(1)
+	}
+
+	ctx, genCtx, cancel := generationContext(parent, timeout)
+	defer cancel()
+
+	if note := plan.scopeNote(); note != "" {
+		fmt.Fprintln(status, note)
+	}
+	if note := plan.ticketNote(); note != "" {
+		fmt.Fprintln(status, note)
+	}
+	if note := plan.budgetNote(); note != "" {
+		fmt.Fprintln(status, note)
+	}
+
+	fmt.Fprintln(status, "Analyzing changes...")
+	start := time.Now()
+
+	// Build the prompt, summarising the diff first if it is too large
+	input, err := plan.render(genCtx, provider)
+	if err != nil {
+		exitIfDone(ctx, genCtx, timeout)
+		// Summarising a large diff is done by the model
+		slog.Error("Failed to build prompt", "error", err)
+		os.Exit(exitProviderFailed)
+	}
+
+	// Generate commit messages. A single message is shown as the model
+	// writes it, unless it answers in JSON; otherwise a spinner shows how
+	// long it is taking.
+	progress, stopProgress := showProgress("Waiting for the model...")
+	var result ai.Result
+	if n == 1 && progress.Live() && !input.JSON {
+		result, err = provider.StreamCommitMessage(genCtx, input, progress.Token)
+	} else {
+		result, err = provider.GenerateCandidates(genCtx, input, n)
+	}
+	stopProgress()
+	if err == nil {
+		plan.answered(provider, result)
+	}
+	messages := plan.finalize(genCtx, provider, input, result.Messages)
+	plan.latency = time.Since(start)
+	if err == nil && len(messages) == 0 {
+		err = errors.New("the model returned an empty message")
+	}
+	if err != nil {
+		exitIfDone(ctx, genCtx, timeout)
+		slog.Error("Failed to generate commit message", "error", err)
+		os.Exit(exitProviderFailed)
+	}
+	slog.Info("Generated commit message", "provider", plan.provider, "model", plan.model)
+
+	more := func() ([]string, error) {
+		_, genCtx, cancel := generationContext(parent, timeout)
+		defer cancel()
+		_, stopProgress := showProgress("Generating more suggestions...")
+		result, err := provider.GenerateCandidates(genCtx, input, max(n, 2))
+		stopProgress()
+		return plan.finalize(genCtx, provider, input, result.Messages), err
+	}
+	return messages, more
+}
+
+// showProgress draws progress on the status output until the returned
+// function is called. Meanwhile status messages, such as a provider falling
+// back to the next, are printed above it.
+func showProgress(label string) (*cli.Progress, func()) {
+	progress := cli.StartProgress(status, label)
+	previous := status
+	status = progress
+	return progress, func() {
+		progress.Stop()
+		status = previous
+	}
+}
+
+// answered records which provider and model produced result, the answer to
+// the main request
+func (p *promptPlan) answered(provider *ai.ChainProvider, result ai.Result) {
+	p.provider = provider.Used()
+	p.model = result.Model
+	if p.model == "" {
+		p.model = provider.Model()
+	}
+}
+
+// finalize cleans up the model's output, repairs what breaks the commit
+// rules, and asks the model once to correct anything that can't be repaired
+// automatically. Messages are rendered in the configured style.
+func (p *promptPlan) finalize(ctx context.Context, provider ai.Provider, input prompt.Prompt, raw []string) []string {
+	var messages []string
+	for _, text := range raw {
+		if p.freeform {
+			if m, err := commit.Parse(commit.Clean(text)); err == nil {
+				messages = append(messages, p.addTicket(m.Render(p.style)))
+			}
+			continue
+		}
+
+		m, violations, err := p.rules.Normalize(text)
+		if err != nil {
+			continue
+		}
+
+		if problems := errorMessages(violations); len(problems) > 0 {
+			corrected, err := provider.GenerateCommitMessage(ctx, prompt.Correction(input, text, problems))
+			if errors.Is(err, ai.ErrDiffOnly) {
+				p.skip("corrections of messages that break the rules")
+			}
+			if err == nil {
+				if fixed, remaining, err := p.rules.Normalize(corrected.Message()); err == nil && len(errorMessages(remaining)) < len(problems) {
+					m, violations = fixed, remaining
+				}
+			}
+		}
+		for _, v := range violations {
+			slog.Warn("Commit message breaks a rule", "rule", v.Rule, "problem", v.Message, "warning", v.Warning)
+		}
+
+		messages = append(messages, p.addTicket(commit.Render(p.rules.Convention, m, p.style)))
+	}
+	return ai.Dedupe(messages)
+}
+
+// skip records that step was left out because the provider only reads the
+// diff
+func (p *promptPlan) skip(step string) {
+	for _, s := range p.skipped {
+		if s == step {
+			return
+		}
+	}
+	fmt.Fprintf(status, "Skipped %s: the provider only reads the diff, not the instructions.\n", step)
+	p.skipped = append(p.skipped, step)
+}
+
+// errorMessages describes the violations that aren't just warnings
+func errorMessages(violations []commit.Violation) []string {
+	var problems []string
+	for _, v := range violations {
+		if !v.Warning {
+			problems = append(problems, v.Message)
+		}
+	}
+	return problems
+}
+
+// commitRules returns the rules generated messages must follow. A commitlint
+// configuration in the repository takes precedence over git-msg's own
+// types and scopes, so generated messages pass the same checks as CI.
+func commitRules(cfg *config.Config, root string) (rules commit.Rules, commitlint bool, err error) {
+	convention, err := commit.Lookup(cfg.Convention)
+	if err != nil {
+		return rules, false, err
+	}
+	rules = commit.Rules{Convention: convention, Scopes: cfg.CommitScopes, Types: cfg.CommitTypes}
+	if len(rules.Types) == 0 {
+		for _, t := range convention.DefaultTypes() {
+			rules.Types = append(rules.Types, t.Name)
+		}
+	}
+	if !cfg.Commitlint || root == "" {
+		return rules, false, nil
+	}
+
+	// A configuration git-msg can't read mustn't stop commits; CI still
+	// runs commitlint itself
+	lint, err := commit.LoadCommitlint(root)
+	if err != nil {
+		slog.Warn("Ignoring the commitlint configuration", "error", err)
+		return rules, false, nil
+	}
+	if lint == nil {
+		return rules, false, nil
+	}
+	if len(lint.Ignored) > 0 {
+		slog.Warn("Skipping commitlint settings git-msg can't read or check", "config", lint.Path, "settings", lint.Ignored)
+	}
+
+	types, scopes := rules.Types, rules.Scopes
+	rules = lint.Rules
+	rules.Convention = convention
+	if len(rules.Types) == 0 {
+		rules.Types = types
+	}
+	if len(rules.Scopes) == 0 {
+		rules.Scopes = scopes
+	}
+	return rules, true, nil
+}
+
+// inferScopes resolves the scopes of the changed files and makes them a
+// requirement of rules. Scopes rules don't allow are dropped, and no scope
+// is imposed on changes spanning more than the configured maximum or when
+// the convention has no scopes.
+func inferScopes(cfg *config.Config, root string, files []*git.FileDiff, rules *commit.Rules) ([]scope.Scope, error) {
+	if !cfg.ScopeInference.Enabled || !rules.Convention.Scoped() {
+		return nil, nil
+	}
+	resolver, err := cfg.ScopeInference.Resolver(root)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[i] = f.Path()
+	}
+
+	var scopes []scope.Scope
+	for _, s := range resolver.Resolve(paths) {
+		if len(rules.Scopes) == 0 {
+			scopes = append(scopes, s)
+			continue
+		}
+		for _, allowed := range rules.Scopes {
+			if strings.EqualFold(allowed, s.Name) {
+				s.Name = allowed
+				scopes = append(scopes, s)
+				break
+			}
+		}
+	}
+	if len(scopes) == 0 || len(scopes) > cfg.ScopeInference.MaxScopes {
+		return nil, nil
+	}
+
+	rules.Scopes = nil
+	for _, s := range scopes {
+		rules.Scopes = append(rules.Scopes, s.Name)
+	}
+	rules.RequireScope = true
+	return scopes, nil
+}
+
+// maxExampleLines limits how much of each example message is shown to the
+// model
+const maxExampleLines = 15
+
+// applyStyle adds the repository's commit style to data. With the "auto"
+// style, the style is learnt from recent history, and a history that doesn't
+// follow Conventional Commits frees messages from it, unless a commitlint
+// configuration requires it or another convention was chosen. The "custom"
+// style leaves the style to the prompt template. Commits in exclude aren't
+// learnt from. It returns whether messages are freeform.
+func applyStyle(cfg *config.Config, data *prompt.Data, commitlint bool, exclude map[string]bool) bool {
+	switch cfg.Style {
+	case "custom":
+		data.Freeform = true
+	case "auto":
+		style, err := git.AnalyzeStyle(cfg.StyleSamples, exclude)
+		if err != nil || style.Sampled == 0 {
+			break
+		}
+		data.StyleGuide = style.Guide()
+		data.Freeform = !style.IsConventional() && !commitlint && cfg.Convention == commit.Conventional.Name()
+		for _, example := range style.Examples {
+			lines := strings.Split(example, "\n")
+			if cfg.MessageStyle != prompt.StyleFull {
+				lines = lines[:1]
+			} else if len(lines) > maxExampleLines {
+				lines = lines[:maxExampleLines]
+			}
+			data.Examples = append(data.Examples, strings.Join(lines, "\n"))
+		}
+	}
+	return data.Freeform
+}
+
+// validateStyle checks the value of the --style flag
+func validateStyle(style string) error {
+	_, err := commit.ParseStyle(style)
+	return err
+}
+
+// generationContext returns ctx, cancelled on Ctrl-C or SIGTERM, and
+// genCtx, which is also cancelled after timeout. Once generation is done and
+// cancel has been called, Ctrl-C terminates the process as usual.
+func generationContext(parent context.Context, timeout time.Duration) (ctx, genCtx context.Context, cancel func()) {
+	ctx, stop := signal.NotifyContext(parent, os.Interrupt, syscall.SIGTERM)
+	if timeout <= 0 {
+		return ctx, ctx, stop
+	}
+
+	genCtx, cancelTimeout := context.WithTimeout(ctx, timeout)
+	return ctx, genCtx, func() {
+		cancelTimeout()
+		stop()
+	}
+}
+
+// exitIfDone terminates the process when generation stopped because the user
+// interrupted it or the deadline passed, so no fallback is attempted
+func exitIfDone(ctx, genCtx context.Context, timeout time.Duration) {
+	if ctx.Err() != nil {
+		fmt.Fprintln(os.Stderr, "Interrupted.")
+		os.Exit(130)
+	}
+	if errors.Is(genCtx.Err(), context.DeadlineExceeded) {
+		slog.Error("Timed out waiting for the model", "timeout", timeout)
+		os.Exit(exitProviderFailed)
+	}
+}
+
+// checkRedactions applies the on_secret policy when a high-severity secret
+// was redacted, returning whether the diff may be sent. Without a user to
+// ask, "ask" blocks.
+func checkRedactions(cfg *config.Config, report redact.Report, interactive bool) bool {
+	if report.Empty() || report.Highest() < redact.High {
+		return true
+	}
+
+	switch {
+	case cfg.Redaction.OnSecret == "redact":
+		return true
+	case cfg.Redaction.OnSecret == "block" || !interactive:
+		fmt.Fprintln(os.Stderr, "Secrets found in the staged changes:")
+		for _, line := range report.Lines() {
+			fmt.Fprintf(os.Stderr, "  %s\n", line)
+		}
+		fmt.Fprintln(os.Stderr, "Refusing to send the diff. Remove the secrets, add them to redaction.allow, or set redaction.on_secret to \"redact\".")
+		return false
+	default:
+		fmt.Fprintln(status, "Secrets found in the staged changes:")
+		for _, line := range report.Lines() {
+			fmt.Fprintf(status, "  %s\n", line)
+		}
+		if cli.Confirm("Send the redacted diff to the model?") {
+			return true
+		}
+		fmt.Fprintln(status, "Operation cancelled.")
+		return false
+	}
+}
+
+// promptPlan is a prompt ready to be rendered, together with the decision
+// of how much of the diff it can hold
+type promptPlan struct {
+	tmpl *prompt.Template
+	data prompt.Data
+	plan budget.Plan
+	// inputBudget is how many tokens of prompt the model accepts
+	inputBudget int
+	// redactions lists the sensitive values removed from the diff
+	redactions redact.Report
+	// rules are the constraints generated messages are checked against
+	rules commit.Rules
+	// scopes were inferred from the changed paths
+	scopes []scope.Scope
+	// ticket is the reference taken from branch, added to messages where
+	// the rules place it
+	ticket string
+	branch string
+	footer string
+	// freeform is set when messages follow the repository's own style
+	// rather than Conventional Commits, so rules don't apply
+	freeform bool
+	style    commit.Style
+	// provider and model wrote the messages; corrections made afterwards,
+	// whichever provider answers them, don't change them
+	provider string
+	model    string
+	// latency is how long generating the messages took
+	latency time.Duration
+	// skipped lists the steps left out because no provider could follow
+	// their instructions
+	skipped []string
+}
+
+// planPrompt prepares the configured prompt template for diff, adding
+// repository context where it is available. Files excluded by the path
+// filters are listed by name only, secrets are replaced with placeholders,
+// and diffs too large for the primary model are trimmed or marked for
+// summarising file by file.
+func planPrompt(cfg *config.Config, diff string) (*promptPlan, error) {
+	files, err := git.ParseDiff(diff)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse diff: %w", err)
+	}
+
+	root, _ := git.RepoRoot()
+	tmpl, err := prompt.Load(cfg.PromptTemplate, root)
+	if err != nil {
+		return nil, err
+	}
+
+	pathFilter, err := newPathFilter(cfg, root)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, commitlint, err := commitRules(cfg, root)
+	if err != nil {
+		return nil, err
+	}
+
+	// The branch is helpful context but not essential
+	branch, _ := git.CurrentBranch()
+	ticket, err := branchTicket(cfg, branch)
+	if err != nil {
+		return nil, err
+	}
+	rules.Ticket = ticket
+	rules.TicketPlacement = commit.TicketPlacement(cfg.Ticket.Placement)
+	rules.TicketPlacement = rules.EffectiveTicketPlacement()
+	// Git removes comment lines from messages it opens in the editor, so a
+	// ticket mentioned only there doesn't count
+	if char := git.CommentChar(); char != "auto" {
+		rules.CommentChar = char
+	}
+
+	// A ticket placed in the scope takes the place of an inferred one
+	var scopes []scope.Scope
+	if ticket == "" || rules.TicketPlacement != commit.TicketScope {
+		if scopes, err = inferScopes(cfg, root, files, &rules); err != nil {
+			return nil, err
+		}
+	}
+
+	var sent []*git.FileDiff
+	data := prompt.Data{
+		Types:       prompt.TypesFromNames(rules.Types),
+		Scopes:      rules.Scopes,
+		Convention:  rules.Convention,
+		Language:    cfg.Language,
+		Style:       cfg.MessageStyle,
+		Constraints: rules.Constraints(),
+	}
+	freeform := applyStyle(cfg, &data, commitlint, nil)
+	for _, f := range files {
+		pf := promptFile(f)
+		if pathFilter.Allowed(f.Path()) {
+			sent = append(sent, f)
+		} else {
+			pf.Omitted = true
+		}
+		data.Files = append(data.Files, pf)
+	}
+
+	data.Branch = branch
+	if subjects, err := git.RecentSubjects(cfg.RecentCommits); err == nil {
+		data.RecentCommits = subjects
+	}
+
+	pieces := budgetFiles(sent)
+	var report redact.Report
+	if cfg.Redaction.Enabled {
+		opts, err := cfg.Redaction.Options()
+		if err != nil {
+			return nil, err
+		}
+		redactor := redact.New(opts)
+		for i := range pieces {
+			pieces[i].Text = redactor.Redact(pieces[i].Path, pieces[i].Text)
+		}
+		// The repository context is sent along with the diff
+		data.Branch = redactor.Redact("the branch name", data.Branch)
+		for i, subject := range data.RecentCommits {
+			data.RecentCommits[i] = redactor.Redact("recent commits", subject)
+		}
+		for i, example := range data.Examples {
+			data.Examples[i] = redactor.Redact("example commits", example)
+		}
+		report = redactor.Report()
+	}
+
+	// Measure the prompt without the diff to see how much room is left for it
+	base, err := tmpl.Render(data)
+	if err != nil {
+		return nil, err
+	}
+	inputBudget := cfg.InputBudget()
+	available := inputBudget - budget.EstimateTokens(base.Text())
+
+	return &promptPlan{
+		tmpl:        tmpl,
+		data:        data,
+		plan:        budget.NewPlan(pieces, available),
+		inputBudget: inputBudget,
+		redactions:  report,
+		rules:       rules,
+		scopes:      scopes,
+		ticket:      ticket,
+		branch:      branch,
+		footer:      cfg.Ticket.Footer,
+		freeform:    freeform,
+		style:       commit.Style(cfg.MessageStyle),
+	}, nil
+}
+
+// branchTicket finds the ticket reference in branch, if ticket references
+// are enabled
+func branchTicket(cfg *config.Config, branch string) (string, error) {
+	if !cfg.Ticket.Enabled || branch == "" {
+		return "", nil
+	}
+	patterns, err := cfg.Ticket.Matchers()
+	if err != nil {
+		return "", err
+	}
+	return git.TicketFromBranch(branch, patterns), nil
+}
+
+// addTicket adds the branch's ticket reference to message where the
+// configuration places it, unless the message already mentions it
+func (p *promptPlan) addTicket(message string) string {
+	convention := p.rules.Convention
+	if p.freeform {
+		convention = nil
+	}
+	return commit.AddTicket(convention, message, p.ticket, p.rules.TicketPlacement, p.footer, p.rules.CommentChar)
+}
+
+// ensureTicket checks that the message about to be written still mentions
+// the branch's ticket, adding it back if editing removed it
+func (p *promptPlan) ensureTicket(message string) string {
+	if p.ticket == "" || commit.HasTicket(message, p.ticket, p.rules.CommentChar) {
+		return message
+	}
+	fmt.Fprintf(status, "Added the ticket reference %s, which the message no longer mentioned.\n", p.ticket)
+	return p.addTicket(message)
+}
+
+// ticketNote describes the ticket taken from the branch name, or returns an
+// empty string when there is none
+func (p *promptPlan) ticketNote() string {
+	if p.ticket == "" {
+		return ""
+	}
+	return fmt.Sprintf("Ticket: %s (branch %s)", p.ticket, p.branch)
+}
+
+// redactionNotes lists what was redacted, for the approval prompt
+func (p *promptPlan) redactionNotes() []string {
+	var notes []string
+	for _, line := range p.redactions.Lines() {
+		notes = append(notes, "Redacted: "+line)
+	}
+	return notes
+}
+
+// scopeNote describes the inferred scopes, or returns an empty string when
+// there are none
+func (p *promptPlan) scopeNote() string {
+	switch len(p.scopes) {
+	case 0:
+		return ""
+	case 1:
+		return "Scope: " + p.scopes[0].String()
+	}
+	names := make([]string, len(p.scopes))
+	for i, s := range p.scopes {
+		names[i] = s.String()
+	}
+	return "Scopes: " + strings.Join(names, ", ")
+}
+
+// budgetNote describes how the diff was fitted into the model's context,
+// or returns an empty string when it is sent in full
+func (p *promptPlan) budgetNote() string {
+	if p.plan.Strategy == budget.Full && !p.plan.Overflow {
+		return ""
+	}
+	return p.plan.Summary()
+}
+
+// render produces the final prompt, first asking provider to summarise each
+// file when the diff is too large to send at once
+func (p *promptPlan) render(ctx context.Context, provider ai.Provider) (prompt.Prompt, error) {
+	data := p.data
+	if p.plan.Strategy != budget.MapReduce {
+		data.Diff = p.plan.Diff()
+		return p.tmpl.Render(data)
+	}
+
+	var err error
+	data.Summaries, err = ai.SummarizeFiles(ctx, provider, p.plan.Files, func(i int, path string) {
+		fmt.Fprintf(status, "Summarising %s (%d/%d)...\n", path, i+1, len(p.plan.Files))
+	})
+	if errors.Is(err, ai.ErrDiffOnly) {
+		// The provider reads the raw diff however the prompt describes it
+		p.skip("summaries of large diffs")
+		data.Diff = p.plan.Diff()
+		return p.tmpl.Render(data)
+	}
+	if err != nil {
+		return prompt.Prompt{}, err
+	}
+	// Templates written for a diff get the summaries in its place
+	data.Diff = prompt.SummaryDiff(data.Summaries)
+
+	input, err := p.tmpl.Render(data)
+	if err != nil {
+		return prompt.Prompt{}, err
+	}
+	if tokens := budget.EstimateTokens(input.Text()); tokens > p.inputBudget {
+		return prompt.Prompt{}, fmt.Errorf("the prompt built from %d file summaries is ~%d tokens, over the budget of %d; commit fewer files at once or raise max_input_tokens",
+			len(data.Summaries), tokens, p.inputBudget)
+	}
+	// Providers with their own request format still get the raw diff
+	input.Diff = p.plan.Diff()
+	return input, nil
+}
+
+// show writes exactly what will be sent to the model. For diffs that are
+// summarised first, that is the per-file requests; the final prompt is then
+// built from the model's summaries.
+func (p *promptPlan) show(w io.Writer) error {
+	if p.plan.Strategy != budget.MapReduce {
+		data := p.data
+		data.Diff = p.plan.Diff()
+		input, err := p.tmpl.Render(data)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, input.Text())
+		return nil
+	}
+
+	for i, f := range p.plan.Files {
+		fmt.Fprintf(w, "=== Summary request %d/%d: %s ===\n", i+1, len(p.plan.Files), f.Path)
+		fmt.Fprintln(w, prompt.Summary(f.Path, f.Text).Text())
+	}
+	fmt.Fprintln(w, "=== The commit message is then requested from the summaries ===")
+	return nil
+}
+
+// newPathFilter builds the filter deciding which files' content is sent:
+// lockfiles and generated files by default, then the config and the
+// repository's .gitmsgignore, either of which can re-include them with "!"
+func newPathFilter(cfg *config.Config, root string) (*filter.Filter, error) {
+	exclude := append(append([]string{}, budget.LowValuePaths...), cfg.ExcludePaths...)
+	if root != "" {
+		patterns, err := filter.ReadIgnoreFile(filepath.Join(root, filter.IgnoreFile))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", filter.IgnoreFile, err)
+		}
+		exclude = append(exclude, patterns...)
+	}
+	return filter.New(cfg.IncludePaths, exclude)
+}
+
+// promptFile describes a changed file for the prompt
+func promptFile(f *git.FileDiff) prompt.File {
+	return prompt.File{
+		Path:     f.Path(),
+		Status:   string(f.Status),
+		Language: f.Language,
+		Added:    f.Added(),
+		Removed:  f.Removed(),
+		Binary:   f.Binary,
+	}
+}
+
+// budgetFiles splits the diff into per-file pieces for budgeting
+func budgetFiles(files []*git.FileDiff) []budget.File {
+	out := make([]budget.File, len(files))
+	for i, f := range files {
+		out[i] = budget.File{Path: f.Path(), Text: f.String()}
+	}
+	return out
+}
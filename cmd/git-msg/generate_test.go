@@ -0,0 +1,24 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/AlexThuku/GitCommitAI-/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPathFilter(t *testing.T) {
+	f, err := newPathFilter(&config.Config{ExcludePaths: []string{"!go.sum", "docs/"}}, "")
+	require.NoError(t, err)
+
+	// Lockfiles and generated files are excluded by default
+	assert.False(t, f.Allowed("web/package-lock.json"))
+	assert.False(t, f.Allowed("api/v1/service.pb.go"))
+	assert.False(t, f.Allowed("vendor/github.com/x/y.go"))
+	assert.False(t, f.Allowed("docs/index.md"))
+	assert.True(t, f.Allowed("main.go"))
+
+	// unless the configuration re-includes them
+	assert.True(t, f.Allowed("go.sum"))
+}
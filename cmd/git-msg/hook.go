@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/AlexThuku/GitCommitAI-/internal/ai"
+	"github.com/AlexThuku/GitCommitAI-/internal/config"
+	"github.com/AlexThuku/GitCommitAI-/internal/git"
+	"github.com/AlexThuku/GitCommitAI-/internal/redact"
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/slog"
+)
+
+// hookCommands maps the hooks git-msg can be installed as to the command
+// each runs
+var hookCommands = map[string][]string{
+	"prepare-commit-msg": {"hook", "run"},
+	"commit-msg":         {"lint"},
+}
+
+// hookNames lists the keys of hookCommands for shell completion
+var hookNames = []string{"prepare-commit-msg", "commit-msg"}
+
+// hookArgs accepts an optional hook name
+func hookArgs(cmd *cobra.Command, args []string) error {
+	if err := cobra.MaximumNArgs(1)(cmd, args); err != nil {
+		return err
+	}
+	if len(args) == 1 {
+		if _, ok := hookCommands[args[0]]; !ok {
+			return fmt.Errorf("unsupported hook: %s (expected \"prepare-commit-msg\" or \"commit-msg\")", args[0])
+		}
+	}
+	return nil
+}
+
+// hookArg returns the hook named in args, or prepare-commit-msg
+func hookArg(args []string) string {
+	if len(args) == 0 {
+		return "prepare-commit-msg"
+	}
+	return args[0]
+}
+
+// newHookCmd creates the hook command and its subcommands
+func newHookCmd(cfg *config.Config, provider *ai.ChainProvider) *cobra.Command {
+	hookCmd := &cobra.Command{
+		Use:   "hook",
+		Short: "Manage git-msg's git hooks",
+		Long: `Install git-msg as a git hook in the current repository:
+
+  prepare-commit-msg  generates a message that appears in your editor when you run git commit
+  commit-msg          checks the message you commit with git-msg lint`,
+	}
+
+	hookCmd.AddCommand(&cobra.Command{
+		Use:         "install [prepare-commit-msg|commit-msg]",
+		Short:       "Install a hook in the current repository (default prepare-commit-msg)",
+		Args:        hookArgs,
+		ValidArgs:   hookNames,
+		Annotations: map[string]string{offline: "true"},
+		Run: func(cmd *cobra.Command, args []string) {
+			name := hookArg(args)
+			executable, err := os.Executable()
+			if err == nil {
+				executable, err = filepath.EvalSymlinks(executable)
+			}
+			if err != nil {
+				slog.Error("Failed to locate the git-msg executable", "error", err)
+				os.Exit(1)
+			}
+
+			path, err := git.InstallHook(name, executable, hookCommands[name]...)
+			if err != nil {
+				slog.Error("Failed to install hook", "error", err)
+				os.Exit(1)
+			}
+			fmt.Printf("Installed %s\n", path)
+		},
+	})
+
+	hookCmd.AddCommand(&cobra.Command{
+		Use:         "uninstall [prepare-commit-msg|commit-msg]",
+		Short:       "Remove a hook from the current repository (default prepare-commit-msg)",
+		Args:        hookArgs,
+		ValidArgs:   hookNames,
+		Annotations: map[string]string{offline: "true"},
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := git.UninstallHook(hookArg(args)); err != nil {
+				slog.Error("Failed to uninstall hook", "error", err)
+				os.Exit(1)
+			}
+			fmt.Println("Hook removed.")
+		},
+	})
+
+	hookCmd.AddCommand(&cobra.Command{
+		Use:    "run <message-file> [<source> [<sha>]]",
+		Short:  "Run as a prepare-commit-msg hook (called by git)",
+		Args:   cobra.RangeArgs(1, 3),
+		Hidden: true,
+		// Missing provider settings are reported by runHook, without
+		// failing the commit
+		Annotations: map[string]string{offline: "true"},
+		Run: func(cmd *cobra.Command, args []string) {
+			var source string
+			if len(args) > 1 {
+				source = args[1]
+			}
+
+			// A failure here must never stop the commit; the user simply
+			// gets git's usual empty message
+			if err := runHook(cmd.Context(), cfg, provider, args[0], source); err != nil {
+				slog.Warn("git-msg could not generate a commit message", "error", err)
+			}
+		},
+	})
+
+	return hookCmd
+}
+
+// skipHook reports whether a commit's message comes from somewhere else:
+// -m/-F, a merge, a squash, or -c/-C/--amend
+func skipHook(source string) bool {
+	switch source {
+	case "message", "merge", "squash", "commit":
+		return true
+	}
+	return false
+}
+
+// runHook generates a message for the staged changes and writes it to the
+// start of messageFile, keeping git's comments and any commit template
+func runHook(ctx context.Context, cfg *config.Config, provider *ai.ChainProvider, messageFile, source string) error {
+	if skipHook(source) {
+		return nil
+	}
+	if err := cfg.ValidateProvider(); err != nil {
+		return err
+	}
+
+	diff, err := git.StagedDiff()
+	if err != nil {
+		return fmt.Errorf("failed to get staged diff: %w", err)
+	}
+	if diff == "" {
+		return nil
+	}
+
+	plan, err := planPrompt(cfg, diff)
+	if err != nil {
+		return err
+	}
+
+	// Git gives hooks no terminal to ask on, so "ask" behaves like "block"
+	report := plan.redactions
+	if report.Highest() == redact.High && cfg.Redaction.OnSecret != "redact" {
+		return errors.New("secrets found in the staged changes; run git-msg generate to review them")
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Timeout)
+		defer cancel()
+	}
+
+	fmt.Fprintln(os.Stderr, "git-msg: generating commit message...")
+	input, err := plan.render(ctx, provider)
+	if err != nil {
+		return err
+	}
+	result, err := provider.GenerateCommitMessage(ctx, input)
+	if err != nil {
+		return err
+	}
+	messages := plan.finalize(ctx, provider, input, result.Messages)
+	if len(messages) == 0 {
+		return errors.New("the model returned an empty message")
+	}
+	message := messages[0]
+
+	existing, err := os.ReadFile(messageFile)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	b.WriteString(strings.TrimSpace(message))
+	b.WriteString("\n")
+	// With core.commentChar set to auto, the character git picked isn't
+	// recorded anywhere, so the notes are left out
+	var comments []string
+	if char := git.CommentChar(); char != "auto" {
+		for _, s := range plan.scopes {
+			comments = append(comments, char+" git-msg scope: "+s.String())
+		}
+		if plan.ticket != "" {
+			comments = append(comments, char+" git-msg ticket: "+plan.ticket+" (branch "+plan.branch+")")
+		}
+		for _, line := range report.Lines() {
+			comments = append(comments, char+" git-msg redacted: "+line)
+		}
+	}
+	if len(comments) > 0 {
+		b.WriteString("\n" + strings.Join(comments, "\n") + "\n")
+	}
+	if len(existing) > 0 && existing[0] != '\n' {
+		b.WriteString("\n")
+	}
+	b.Write(existing)
+
+	return os.WriteFile(messageFile, []byte(b.String()), 0644)
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/AlexThuku/GitCommitAI-/internal/ai"
+	"github.com/AlexThuku/GitCommitAI-/internal/cli"
+	"github.com/spf13/cobra"
+)
+
+// Exit codes, so scripts can tell why git-msg stopped. Anything else that
+// goes wrong exits with 1, and an interrupt with 130.
+const (
+	// exitNoChanges means there was nothing to describe
+	exitNoChanges = 2
+	// exitProviderFailed means no provider produced a message in time
+	exitProviderFailed = 3
+	// exitValidationFailed means no message met the commit rules
+	exitValidationFailed = 4
+	// exitRejected means the user rejected the message
+	exitRejected = 5
+)
+
+// status receives progress messages. With --print it is stderr, so that
+// stdout holds nothing but the message.
+var status io.Writer = os.Stdout
+
+// interaction is how a command settles on a message: by asking the user, or
+// on its own when there is nobody to ask
+type interaction struct {
+	// yes accepts the best message without asking
+	yes bool
+	// print writes only the message to stdout
+	print bool
+	// output is a file to write the message to
+	output string
+	// format is what generate writes: the message, or a JSON report
+	format string
+	// pick is the value of --pick
+	pick string
+}
+
+// addFlags registers the flags that make a command non-interactive. Only
+// generate writes the message anywhere but the commit.
+func (i *interaction) addFlags(cmd *cobra.Command, writes bool) {
+	flags := cmd.Flags()
+	flags.StringVar(&i.pick, "pick", "", "choose a message without asking: \"best\" picks the highest-scoring one")
+	flags.BoolVarP(&i.yes, "yes", "y", false, "accept the best message that meets the commit rules without asking")
+	if writes {
+		flags.BoolVar(&i.print, "print", false, "write only the message to stdout instead of setting it, and progress to stderr")
+		flags.StringVarP(&i.output, "output", "o", "", "write the message to a file instead of setting it")
+		flags.StringVar(&i.format, "format", formatText, "what --print and --output write: \"text\" or \"json\" (the message with generation metadata)")
+	}
+}
+
+// Output formats of generate
+const (
+	formatText = "text"
+	formatJSON = "json"
+)
+
+// validate checks the flags and redirects progress output for --print. A
+// JSON report is printed unless it goes to a file.
+func (i *interaction) validate() error {
+	if err := validatePick(i.pick); err != nil {
+		return err
+	}
+	switch i.format {
+	case "", formatText:
+	case formatJSON:
+		i.print = i.output == ""
+	default:
+		return fmt.Errorf("invalid format: %s (expected \"text\" or \"json\")", i.format)
+	}
+	if i.print {
+		status = os.Stderr
+	}
+	return nil
+}
+
+// interactive reports whether the user is asked to approve messages. Nobody
+// is asked when stdin isn't a terminal, e.g. in CI or an editor integration.
+func (i *interaction) interactive() bool {
+	return !i.yes && !i.print && i.output == "" && i.pick != pickBest && cli.Interactive()
+}
+
+// chooseMessage lets the user approve one of messages. Without a user, it
+// picks the best message that meets the commit rules, exiting when none
+// does.
+func chooseMessage(plan *promptPlan, messages []string, more func() ([]string, error), mode *interaction) (bool, string) {
+	if !mode.interactive() {
+		message, problems := plan.best(messages)
+		if len(problems) > 0 {
+			fmt.Fprintln(os.Stderr, "No suggested message meets the commit rules:")
+			for _, problem := range problems {
+				fmt.Fprintf(os.Stderr, "  %s\n", problem)
+			}
+			os.Exit(exitValidationFailed)
+		}
+		fmt.Fprintf(status, "Picked commit: \"%s\"\n", message)
+		return true, message
+	}
+
+	if len(messages) == 1 {
+		return cli.PromptForApproval(messages[0], more, plan.redactionNotes()...)
+	}
+	return cli.ChooseCandidate(messages, more, plan.redactionNotes()...)
+}
+
+// best returns the highest-scoring of messages that meet the commit rules.
+// When none does, it returns the problems of the best one instead.
+func (p *promptPlan) best(messages []string) (string, []string) {
+	if p.freeform {
+		return ai.Best(messages), nil
+	}
+
+	var valid []string
+	for _, message := range messages {
+		if len(errorMessages(p.rules.Lint(message))) == 0 {
+			valid = append(valid, message)
+		}
+	}
+	if len(valid) == 0 {
+		return "", errorMessages(p.rules.Lint(ai.Best(messages)))
+	}
+	return ai.Best(valid), nil
+}
+
+// pickBest selects the best candidate without asking
+const pickBest = "best"
+
+// validatePick checks the value of the --pick flag
+func validatePick(pick string) error {
+	if pick != "" && pick != pickBest {
+		return fmt.Errorf("invalid --pick: %s (expected \"best\")", pick)
+	}
+	return nil
+}
+
+// writeMessage delivers an approved message, or its JSON report: to stdout
+// with --print, to a file with --output, and otherwise through set
+func (i *interaction) writeMessage(message string, set func(string) error) error {
+	if i.print {
+		_, err := fmt.Fprintln(os.Stdout, message)
+		return err
+	}
+	if i.output != "" {
+		return os.WriteFile(i.output, []byte(message+"\n"), 0o644)
+	}
+	return set(message)
+}
@@ -0,0 +1,350 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/AlexThuku/GitCommitAI-/internal/config"
+	"github.com/AlexThuku/GitCommitAI-/internal/git"
+	"github.com/AlexThuku/GitCommitAI-/internal/prompt"
+	"github.com/AlexThuku/GitCommitAI-/pkg/commit"
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/slog"
+)
+
+// lintFormats are the output formats of the lint command
+var lintFormats = []string{"text", "json", "github", "gitlab"}
+
+// lintResult is the outcome of linting one message
+type lintResult struct {
+	// Source is the file the message was read from, "-" for standard
+	// input, or empty for a commit
+	Source     string
+	Commit     string
+	Header     string
+	Violations []commit.Violation
+}
+
+// name identifies the message in output
+func (r lintResult) name() string {
+	switch {
+	case r.Commit != "":
+		return shortHash(r.Commit)
+	case r.Source == "-":
+		return "stdin"
+	}
+	return r.Source
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
+
+func severity(v commit.Violation) string {
+	if v.Warning {
+		return "warning"
+	}
+	return "error"
+}
+
+// newLintCmd creates the lint command
+func newLintCmd(cfg *config.Config) *cobra.Command {
+	var rangeSpec, format string
+
+	cmd := &cobra.Command{
+		Use:   "lint [file|-]",
+		Short: "Check commit messages against the commit rules",
+		Long: `Check commit messages against the same rules generated messages follow:
+Conventional Commits, the configured types and scopes, and the repository's
+commitlint configuration. When the style frees messages from the convention,
+as "custom" does, only the description and line lengths are checked.
+
+Lint a message file, such as .git/COMMIT_EDITMSG, or standard input with "-"
+or no argument. With --range, lint every commit in a revision range, e.g.
+--range origin/main..HEAD in CI. Exits with status 1 if any message has errors.`,
+		Args:        cobra.MaximumNArgs(1),
+		Annotations: map[string]string{offline: "true"},
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if rangeSpec != "" && len(args) > 0 {
+				return fmt.Errorf("--range can't be combined with a message file")
+			}
+			for _, f := range lintFormats {
+				if format == f {
+					return nil
+				}
+			}
+			return fmt.Errorf("invalid format: %s (expected one of %s)", format, strings.Join(lintFormats, ", "))
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			root, _ := git.RepoRoot()
+			rules, commitlint, err := commitRules(cfg, root)
+			if err != nil {
+				slog.Error("Failed to load commit rules", "error", err)
+				os.Exit(1)
+			}
+			// Messages generate writes in the repository's own style
+			// mustn't be rejected for not following the convention
+			rules.Freeform = lintFreeform(cfg, commitlint, rangeSpec)
+			// With "auto", git picks "#" unless a line of the message
+			// already starts with it
+			if char := git.CommentChar(); char != "auto" {
+				rules.CommentChar = char
+			}
+
+			results, err := lintMessages(rules, args, rangeSpec)
+			if err != nil {
+				slog.Error("Failed to read commit messages", "error", err)
+				os.Exit(1)
+			}
+
+			if err := writeLintResults(os.Stdout, format, results); err != nil {
+				slog.Error("Failed to write results", "error", err)
+				os.Exit(1)
+			}
+
+			if lintFailed(results) {
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&rangeSpec, "range", "", "Lint the commits in a revision range, e.g. main..HEAD")
+	cmd.Flags().StringVar(&format, "format", "text", "Output format: text, json, github or gitlab")
+
+	return cmd
+}
+
+// lintFreeform reports whether messages are freeform, as generate decides.
+// The style is learnt from history outside rangeSpec, so that the commits
+// being linted don't decide how strictly they're checked.
+func lintFreeform(cfg *config.Config, commitlint bool, rangeSpec string) bool {
+	var linted map[string]bool
+	if rangeSpec != "" {
+		commits, _ := git.MessagesInRange(rangeSpec)
+		linted = make(map[string]bool, len(commits))
+		for _, c := range commits {
+			linted[c.Hash] = true
+		}
+	}
+	return applyStyle(cfg, &prompt.Data{}, commitlint, linted)
+}
+
+// lintMessages lints the commits in rangeSpec, or else the message file
+// named in args, reading standard input for "-" or no file
+func lintMessages(rules commit.Rules, args []string, rangeSpec string) ([]lintResult, error) {
+	if rangeSpec != "" {
+		commits, err := git.MessagesInRange(rangeSpec)
+		if err != nil {
+			return nil, err
+		}
+		results := make([]lintResult, 0, len(commits))
+		for _, c := range commits {
+			results = append(results, lintResult{
+				Commit:     c.Hash,
+				Header:     firstLine(c.Message),
+				Violations: rules.LintCommitted(c.Message),
+			})
+		}
+		return results, nil
+	}
+
+	source := "-"
+	if len(args) > 0 {
+		source = args[0]
+	}
+	var data []byte
+	var err error
+	if source == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(source)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	message := string(data)
+	return []lintResult{{
+		Source:     source,
+		Header:     firstLine(commit.Strip(message, rules.CommentChar)),
+		Violations: rules.Lint(message),
+	}}, nil
+}
+
+// lintFailed reports whether any message has errors; warnings don't fail
+func lintFailed(results []lintResult) bool {
+	for _, r := range results {
+		for _, v := range r.Violations {
+			if !v.Warning {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
+
+// writeLintResults writes results in format
+func writeLintResults(w io.Writer, format string, results []lintResult) error {
+	switch format {
+	case "json":
+		return writeLintJSON(w, results)
+	case "github":
+		writeLintGitHub(w, results)
+	case "gitlab":
+		return writeLintGitLab(w, results)
+	default:
+		writeLintText(w, results)
+	}
+	return nil
+}
+
+// writeLintText lists each message's problems under its name, and a summary
+func writeLintText(w io.Writer, results []lintResult) {
+	errors, warnings := 0, 0
+	for _, r := range results {
+		if len(r.Violations) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "%s: %s\n", r.name(), r.Header)
+		for _, v := range r.Violations {
+			position := fmt.Sprintf("%d:%d", v.Line, v.Column)
+			fmt.Fprintf(w, "  %-6s %-7s  %s  %s\n", position, severity(v), v.Message, v.Rule)
+			if v.Warning {
+				warnings++
+			} else {
+				errors++
+			}
+		}
+		fmt.Fprintln(w)
+	}
+
+	if errors+warnings > 0 {
+		fmt.Fprintf(w, "%d problems (%d errors, %d warnings)\n", errors+warnings, errors, warnings)
+	}
+}
+
+type lintJSONViolation struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+}
+
+type lintJSONResult struct {
+	Source     string              `json:"source,omitempty"`
+	Commit     string              `json:"commit,omitempty"`
+	Header     string              `json:"header"`
+	Valid      bool                `json:"valid"`
+	Violations []lintJSONViolation `json:"violations"`
+}
+
+func writeLintJSON(w io.Writer, results []lintResult) error {
+	out := make([]lintJSONResult, 0, len(results))
+	for _, r := range results {
+		result := lintJSONResult{
+			Source:     r.Source,
+			Commit:     r.Commit,
+			Header:     r.Header,
+			Valid:      true,
+			Violations: []lintJSONViolation{},
+		}
+		for _, v := range r.Violations {
+			result.Valid = result.Valid && v.Warning
+			result.Violations = append(result.Violations, lintJSONViolation{
+				Rule:     v.Rule,
+				Severity: severity(v),
+				Message:  v.Message,
+				Line:     v.Line,
+				Column:   v.Column,
+			})
+		}
+		out = append(out, result)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// writeLintGitHub writes GitHub Actions workflow commands, which show as
+// annotations on the run and, for files, on the lines they refer to
+func writeLintGitHub(w io.Writer, results []lintResult) {
+	escape := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	escapeProperty := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+
+	for _, r := range results {
+		for _, v := range r.Violations {
+			properties := "title=" + escapeProperty.Replace("git-msg lint: "+v.Rule)
+			message := v.Message
+			if r.Commit != "" {
+				message = fmt.Sprintf("%s %q: %s", shortHash(r.Commit), r.Header, v.Message)
+			} else if r.Source != "-" {
+				properties = fmt.Sprintf("file=%s,line=%d,col=%d,%s", escapeProperty.Replace(r.Source), v.Line, v.Column, properties)
+			}
+			fmt.Fprintf(w, "::%s %s::%s\n", severity(v), properties, escape.Replace(message))
+		}
+	}
+}
+
+type gitLabIssue struct {
+	Description string         `json:"description"`
+	CheckName   string         `json:"check_name"`
+	Fingerprint string         `json:"fingerprint"`
+	Severity    string         `json:"severity"`
+	Location    gitLabLocation `json:"location"`
+}
+
+type gitLabLocation struct {
+	Path  string `json:"path"`
+	Lines struct {
+		Begin int `json:"begin"`
+	} `json:"lines"`
+}
+
+// writeLintGitLab writes a GitLab Code Quality report
+func writeLintGitLab(w io.Writer, results []lintResult) error {
+	issues := []gitLabIssue{}
+	for _, r := range results {
+		for _, v := range r.Violations {
+			issue := gitLabIssue{
+				Description: v.Message,
+				CheckName:   v.Rule,
+				Severity:    "major",
+			}
+			if v.Warning {
+				issue.Severity = "minor"
+			}
+
+			issue.Location.Path = r.Source
+			if r.Commit != "" {
+				// Code Quality reports locate issues in files; a commit's
+				// message is the closest equivalent
+				issue.Location.Path = ".git/COMMIT_EDITMSG"
+				issue.Description = fmt.Sprintf("%s %q: %s", shortHash(r.Commit), r.Header, v.Message)
+			}
+			issue.Location.Lines.Begin = v.Line
+
+			sum := sha1.Sum([]byte(fmt.Sprintf("%s\x00%s\x00%s\x00%d:%d", r.Commit, r.Source, v.Rule, v.Line, v.Column)))
+			issue.Fingerprint = hex.EncodeToString(sum[:])
+			issues = append(issues, issue)
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(issues)
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/AlexThuku/GitCommitAI-/internal/config"
+	"github.com/AlexThuku/GitCommitAI-/pkg/commit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteLintResults(t *testing.T) {
+	fullStop := commit.Violation{Rule: "subject-full-stop", Message: "the subject ends with \"%.\"", Line: 3, Column: 26}
+	longLine := commit.Violation{Rule: "body-max-line-length", Message: "line is 120 characters long", Warning: true, Line: 5, Column: 101}
+
+	tests := []struct {
+		name    string
+		format  string
+		results []lintResult
+		want    string
+		failed  bool
+	}{
+		{
+			name:    "text",
+			format:  "text",
+			results: []lintResult{{Source: "msg.txt", Header: "feat: add x.", Violations: []commit.Violation{fullStop, longLine}}},
+			want: "msg.txt: feat: add x.\n" +
+				"  3:26   error    the subject ends with \"%.\"  subject-full-stop\n" +
+				"  5:101  warning  line is 120 characters long  body-max-line-length\n" +
+				"\n2 problems (1 errors, 1 warnings)\n",
+			failed: true,
+		},
+		{
+			name:    "github file",
+			format:  "github",
+			results: []lintResult{{Source: "dir,a:b.txt", Violations: []commit.Violation{fullStop}}},
+			want:    "::error file=dir%2Ca%3Ab.txt,line=3,col=26,title=git-msg lint%3A subject-full-stop::the subject ends with \"%25.\"\n",
+			failed:  true,
+		},
+		{
+			name:    "github commit",
+			format:  "github",
+			results: []lintResult{{Commit: "0123456789abcdef", Header: "feat: add x.", Violations: []commit.Violation{longLine}}},
+			want:    "::warning title=git-msg lint%3A body-max-line-length::0123456 \"feat: add x.\": line is 120 characters long\n",
+		},
+		{
+			name:    "github stdin",
+			format:  "github",
+			results: []lintResult{{Source: "-", Violations: []commit.Violation{{Rule: "r", Message: "two\nlines"}}}},
+			want:    "::error title=git-msg lint%3A r::two%0Alines\n",
+			failed:  true,
+		},
+		{
+			name:    "clean",
+			format:  "text",
+			results: []lintResult{{Source: "-", Header: "feat: add x"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			require.NoError(t, writeLintResults(&out, tt.format, tt.results))
+			assert.Equal(t, tt.want, out.String())
+			assert.Equal(t, tt.failed, lintFailed(tt.results))
+		})
+	}
+}
+
+func TestWriteLintResultsGitLab(t *testing.T) {
+	results := []lintResult{
+		{Source: "msg.txt", Violations: []commit.Violation{{Rule: "type-enum", Message: "bad type", Line: 2, Column: 1}}},
+		{Commit: "0123456789abcdef", Header: "wip", Violations: []commit.Violation{{Rule: "subject-case", Message: "bad case", Warning: true, Line: 1, Column: 1}}},
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, writeLintResults(&out, "gitlab", results))
+
+	var issues []gitLabIssue
+	require.NoError(t, json.Unmarshal(out.Bytes(), &issues))
+	require.Len(t, issues, 2)
+
+	assert.Equal(t, "type-enum", issues[0].CheckName)
+	assert.Equal(t, "major", issues[0].Severity)
+	assert.Equal(t, "msg.txt", issues[0].Location.Path)
+	assert.Equal(t, 2, issues[0].Location.Lines.Begin)
+
+	assert.Equal(t, "minor", issues[1].Severity)
+	assert.Equal(t, ".git/COMMIT_EDITMSG", issues[1].Location.Path)
+	assert.Equal(t, "0123456 \"wip\": bad case", issues[1].Description)
+
+	assert.Len(t, issues[0].Fingerprint, 40)
+	assert.NotEqual(t, issues[0].Fingerprint, issues[1].Fingerprint)
+	assert.True(t, lintFailed(results))
+	assert.False(t, lintFailed(results[1:]))
+
+	// An empty report is still a valid one
+	out.Reset()
+	require.NoError(t, writeLintResults(&out, "gitlab", nil))
+	assert.Equal(t, "[]\n", out.String())
+}
+
+// inTempRepo runs the test from a fresh repository, returning a function
+// that runs git in it
+func inTempRepo(t *testing.T) func(args ...string) {
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	t.Cleanup(func() { os.Chdir(wd) })
+	require.NoError(t, os.Chdir(t.TempDir()))
+
+	git := func(args ...string) {
+		t.Helper()
+		args = append([]string{"-c", "user.name=t", "-c", "user.email=t@example.com"}, args...)
+		out, err := exec.Command("git", args...).CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	git("init", "-q")
+	return git
+}
+
+func TestLintMessagesRangeKeepsCommentCharLines(t *testing.T) {
+	git := inTempRepo(t)
+	git("commit", "-q", "--allow-empty", "-m", "chore: init")
+	// Git only strips comments when the commit is made
+	git("commit", "-q", "--allow-empty", "--cleanup=verbatim", "-m", "#123 fix login\n\nfix: handle expired sessions")
+
+	rules := commit.Rules{Types: []string{"fix", "chore"}}
+	results, err := lintMessages(rules, nil, "HEAD~1..HEAD")
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "#123 fix login", results[0].Header)
+
+	var got []string
+	for _, v := range results[0].Violations {
+		got = append(got, v.Rule)
+		assert.Equal(t, 1, v.Line)
+	}
+	assert.NotEmpty(t, got)
+	assert.NotContains(t, got, "subject-empty")
+}
+
+func TestLintFreeformIgnoresLintedRange(t *testing.T) {
+	git := inTempRepo(t)
+	for _, message := range []string{"feat: add login", "fix(api): handle empty pages", "docs: describe setup"} {
+		git("commit", "-q", "--allow-empty", "-m", message)
+	}
+	for _, message := range []string{"Updated stuff", "More changes", "Fixed it", "Tidied the README"} {
+		git("commit", "-q", "--allow-empty", "-m", message)
+	}
+
+	cfg := &config.Config{Style: "auto", StyleSamples: 10, Convention: "conventional"}
+	// The range is linted against the style of the history before it
+	assert.False(t, lintFreeform(cfg, false, "HEAD~4..HEAD"))
+	// A message about to be committed follows the history as it is
+	assert.True(t, lintFreeform(cfg, false, ""))
+
+	cfg.Style = "custom"
+	assert.True(t, lintFreeform(cfg, false, "HEAD~4..HEAD"))
+}
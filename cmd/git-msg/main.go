@@ -5,9 +5,7 @@ import (
 	"os"
 
 	"github.com/AlexThuku/GitCommitAI-/internal/ai"
-	"github.com/AlexThuku/GitCommitAI-/internal/cli"
 	"github.com/AlexThuku/GitCommitAI-/internal/config"
-	"github.com/AlexThuku/GitCommitAI-/internal/git"
 	"github.com/spf13/cobra"
 	"golang.org/x/exp/slog"
 )
@@ -26,18 +24,15 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Create AI provider based on configuration
-	var provider ai.Provider
-	switch cfg.ModelProvider {
-	case "openai":
-		provider = ai.NewOpenAIProvider(cfg.OpenAIAPIKey, cfg.OpenAIModel)
-	case "huggingface":
-		provider = ai.NewHuggingFaceProvider(cfg.HuggingFaceToken, cfg.HuggingFaceModel)
-	case "local":
-		provider = ai.NewLocalProvider(cfg.LocalEndpoint)
-	default:
-		// Default to Hugging Face if not specified
-		provider = ai.NewHuggingFaceProvider(cfg.HuggingFaceToken, cfg.HuggingFaceModel)
+	// Create the AI provider chain based on configuration
+	var chain []ai.NamedProvider
+	for _, name := range cfg.ProviderChain() {
+		chain = append(chain, ai.NamedProvider{Name: name, Provider: newProvider(name, cfg)})
+	}
+	provider := ai.NewChainProvider(chain...)
+	provider.OnFailure = func(failed string, err error, next string) {
+		// Through status, so the note is printed above any progress
+		fmt.Fprintf(status, "Provider %s failed: %v\nFalling back to %s...\n", failed, err, next)
 	}
 
 	// Create root command
@@ -45,79 +40,21 @@ func main() {
 		Use:   "git-msg",
 		Short: "AI-powered Git commit message generator",
 		Long:  "Generate meaningful commit messages based on your uncommitted changes using AI",
-	}
-
-	// Add generate command
-	generateCmd := &cobra.Command{
-		Use:   "generate",
-		Short: "Generate a commit message",
-		Run: func(cmd *cobra.Command, args []string) {
-			// Get git diff
-			diff, err := git.GetDiff()
-			if err != nil {
-				slog.Error("Failed to get git diff", "error", err)
-				os.Exit(1)
-			}
-
-			if diff == "" {
-				fmt.Println("No changes detected. Stage your changes first.")
-				os.Exit(0)
-			}
-
-			fmt.Println("Analyzing changes...")
-
-			// Generate commit message
-			message, err := provider.GenerateCommitMessage(diff)
-			if err != nil {
-				slog.Error("Failed to generate commit message", "error", err)
-
-				// Try fallback if primary provider failed
-				var fallbackProvider ai.Provider
-				var fallbackName string
-
-				if _, ok := provider.(*ai.HuggingFaceProvider); ok && cfg.LocalEndpoint != "" {
-					fallbackName = "local model"
-					fallbackProvider = ai.NewLocalProvider(cfg.LocalEndpoint)
-				} else if _, ok := provider.(*ai.LocalProvider); ok && cfg.HuggingFaceToken != "" {
-					fallbackName = "Hugging Face model"
-					fallbackProvider = ai.NewHuggingFaceProvider(cfg.HuggingFaceToken, cfg.HuggingFaceModel)
-				} else if _, ok := provider.(*ai.OpenAIProvider); ok {
-					if cfg.HuggingFaceToken != "" {
-						fallbackName = "Hugging Face model"
-						fallbackProvider = ai.NewHuggingFaceProvider(cfg.HuggingFaceToken, cfg.HuggingFaceModel)
-					} else if cfg.LocalEndpoint != "" {
-						fallbackName = "local model"
-						fallbackProvider = ai.NewLocalProvider(cfg.LocalEndpoint)
-					}
-				}
-
-				if fallbackProvider != nil {
-					fmt.Printf("Falling back to %s...\n", fallbackName)
-					message, err = fallbackProvider.GenerateCommitMessage(diff)
-					if err != nil {
-						slog.Error("Fallback failed", "error", err)
-						os.Exit(1)
-					}
-				} else {
-					os.Exit(1)
-				}
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			if cmd.Annotations[offline] != "" {
+				return
 			}
-
-			// Present to user for approval
-			approved, finalMessage := cli.PromptForApproval(message)
-			if approved {
-				if err := git.SetCommitMessage(finalMessage); err != nil {
-					slog.Error("Failed to set commit message", "error", err)
-					os.Exit(1)
-				}
-				fmt.Println("Commit message set successfully!")
-			} else {
-				fmt.Println("Operation cancelled.")
+			if err := cfg.ValidateProvider(); err != nil {
+				slog.Error("Failed to load configuration", "error", err)
+				os.Exit(1)
 			}
 		},
 	}
 
-	rootCmd.AddCommand(generateCmd)
+	rootCmd.AddCommand(newGenerateCmd(cfg, provider))
+	rootCmd.AddCommand(newCommitCmd(cfg, provider))
+	rootCmd.AddCommand(newHookCmd(cfg, provider))
+	rootCmd.AddCommand(newLintCmd(cfg))
 
 	// Execute command
 	if err := rootCmd.Execute(); err != nil {
@@ -125,3 +62,34 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// offline is the annotation marking commands that don't use a model, so
+// they work without provider credentials, e.g. in CI
+const offline = "offline"
+
+// newProvider creates the AI provider registered under name
+func newProvider(name string, cfg *config.Config) ai.Provider {
+	switch name {
+	case "openai":
+		return ai.NewOpenAIProvider(cfg.OpenAIAPIKey, cfg.OpenAIModel, ai.OpenAISettings{
+			BaseURL:         cfg.OpenAIBaseURL,
+			Organization:    cfg.OpenAIOrganization,
+			Headers:         cfg.OpenAIHeaders,
+			AzureDeployment: cfg.OpenAIAzureDeployment,
+			AzureAPIVersion: cfg.OpenAIAzureAPIVersion,
+		})
+	case "anthropic":
+		return ai.NewAnthropicProvider(cfg.AnthropicAPIKey, cfg.AnthropicModel, cfg.AnthropicBaseURL)
+	case "local":
+		return ai.NewLocalProvider(cfg.LocalEndpoint)
+	case "ollama":
+		return ai.NewOllamaProvider(cfg.OllamaEndpoint, cfg.OllamaModel, ai.OllamaSettings{
+			API:       cfg.OllamaAPI,
+			KeepAlive: cfg.OllamaKeepAlive,
+			Options:   cfg.OllamaOptions,
+		})
+	default:
+		// Default to Hugging Face if not specified
+		return ai.NewHuggingFaceProvider(cfg.HuggingFaceToken, cfg.HuggingFaceModel)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/AlexThuku/GitCommitAI-/internal/ai"
+	"github.com/AlexThuku/GitCommitAI-/internal/budget"
+	"github.com/AlexThuku/GitCommitAI-/pkg/commit"
+)
+
+// generateReport is what generate --format json writes, for editor plugins
+// and bots. Metadata a provider doesn't report is left empty.
+type generateReport struct {
+	Message string `json:"message"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+	Type    string `json:"type"`
+	Scope   string `json:"scope"`
+	// Provider is the name of the provider in the chain that answered
+	Provider  string `json:"provider"`
+	Model     string `json:"model"`
+	LatencyMS int64  `json:"latency_ms"`
+	// TokensIn and TokensOut include summaries and corrections
+	TokensIn  int `json:"tokens_in"`
+	TokensOut int `json:"tokens_out"`
+	// Truncated is set when the model didn't see every hunk in full, because
+	// files were summarised or cut short. Leaving out lockfiles and
+	// generated files doesn't count.
+	Truncated  bool     `json:"truncated"`
+	Redactions []string `json:"redactions"`
+	// Skipped lists steps left out because the provider only reads the
+	// diff, such as summaries and corrections
+	Skipped    []string `json:"skipped"`
+	Candidates []string `json:"candidates"`
+}
+
+// report describes message, chosen from candidates, as JSON
+func (p *promptPlan) report(provider *ai.ChainProvider, message string, candidates []string) (string, error) {
+	convention := p.rules.Convention
+	if p.freeform {
+		convention = commit.Conventional
+	}
+	m, err := commit.ParseWith(convention, message)
+	if err != nil {
+		return "", err
+	}
+
+	usage := provider.Usage()
+	r := generateReport{
+		Message:    message,
+		Subject:    m.Subject,
+		Body:       strings.Join(m.Body, "\n\n"),
+		Type:       m.Type,
+		Scope:      m.Scope,
+		Provider:   p.provider,
+		Model:      p.model,
+		LatencyMS:  p.latency.Milliseconds(),
+		TokensIn:   usage.InputTokens,
+		TokensOut:  usage.OutputTokens,
+		Truncated:  p.plan.Strategy == budget.MapReduce || len(p.plan.Truncated) > 0,
+		Redactions: append([]string{}, p.redactions.Lines()...),
+		Skipped:    append([]string{}, p.skipped...),
+		Candidates: append([]string{}, candidates...),
+	}
+
+	out, err := json.MarshalIndent(r, "", "  ")
+	return string(out), err
+}
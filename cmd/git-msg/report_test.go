@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/AlexThuku/GitCommitAI-/internal/ai"
+	"github.com/AlexThuku/GitCommitAI-/internal/budget"
+	"github.com/AlexThuku/GitCommitAI-/internal/prompt"
+	"github.com/AlexThuku/GitCommitAI-/pkg/commit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scriptedProvider summarises files, answers with a message of the wrong
+// type, and corrects it when asked. Every request costs 10 tokens in and 2
+// out.
+type scriptedProvider struct{}
+
+func (scriptedProvider) GenerateCommitMessage(ctx context.Context, input prompt.Prompt) (ai.Result, error) {
+	reply := "chore: add pagination"
+	switch {
+	case strings.HasPrefix(input.User, "File: "):
+		reply = "adds pagination"
+	case strings.Contains(input.User, "Your previous commit message was"):
+		reply = "feat: add pagination"
+	}
+	return ai.Result{Messages: []string{reply}, Model: "llama3.1:8b", Usage: ai.Usage{InputTokens: 10, OutputTokens: 2}}, nil
+}
+
+// uncorrectingProvider answers like scriptedProvider but fails to correct
+// a message
+type uncorrectingProvider struct{ scriptedProvider }
+
+func (p uncorrectingProvider) GenerateCommitMessage(ctx context.Context, input prompt.Prompt) (ai.Result, error) {
+	if strings.Contains(input.User, "Your previous commit message was") {
+		return ai.Result{}, errors.New("rate limited")
+	}
+	return p.scriptedProvider.GenerateCommitMessage(ctx, input)
+}
+
+// diffOnlyProvider answers like scriptedProvider but, like a local
+// endpoint, can't follow instructions
+type diffOnlyProvider struct{ scriptedProvider }
+
+func (p diffOnlyProvider) GenerateCommitMessage(ctx context.Context, input prompt.Prompt) (ai.Result, error) {
+	if input.NeedsInstructions {
+		return ai.Result{}, ai.ErrDiffOnly
+	}
+	return p.scriptedProvider.GenerateCommitMessage(ctx, input)
+}
+
+type failingProvider struct{}
+
+func (failingProvider) GenerateCommitMessage(ctx context.Context, input prompt.Prompt) (ai.Result, error) {
+	return ai.Result{}, errors.New("rate limited")
+}
+
+func TestPlanReport(t *testing.T) {
+	defer func(w io.Writer) { status = w }(status)
+	status = io.Discard
+
+	files := []budget.File{
+		{Path: "api.go", Text: strings.Repeat("+func list() {}\n", 200)},
+		{Path: "api_test.go", Text: strings.Repeat("+func TestList() {}\n", 200)},
+		{Path: "package-lock.json", Text: strings.Repeat("+    \"integrity\": \"sha512-abc\",\n", 600)},
+	}
+
+	tests := []struct {
+		name      string
+		budget    int
+		strategy  budget.Strategy
+		truncated bool
+		provider  ai.Provider
+		message   string
+		// requests is how many requests the fallback provider answered
+		requests int
+		skipped  []string
+	}{
+		{name: "summarised", budget: 300, strategy: budget.MapReduce, truncated: true, provider: scriptedProvider{}, message: "feat: add pagination", requests: 4},
+		// Only the lockfile is left out, so the model sees every hunk
+		{name: "filtered", budget: 3000, strategy: budget.Filtered, provider: scriptedProvider{}, message: "feat: add pagination", requests: 2},
+		{name: "full", budget: 100000, strategy: budget.Full, provider: scriptedProvider{}, message: "feat: add pagination", requests: 2},
+		// The message is kept as it is when no provider can correct it
+		{name: "correction failed", budget: 100000, strategy: budget.Full, provider: uncorrectingProvider{}, message: "chore: add pagination", requests: 1},
+		// A provider that only reads the diff gets it raw, and is never
+		// asked to correct the message
+		{name: "diff only", budget: 300, strategy: budget.MapReduce, truncated: true, provider: diffOnlyProvider{}, message: "chore: add pagination", requests: 1,
+			skipped: []string{"summaries of large diffs", "corrections of messages that break the rules"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := &promptPlan{
+				tmpl:        prompt.Default(),
+				plan:        budget.NewPlan(files, tt.budget),
+				inputBudget: 100000,
+				rules:       commit.Rules{Convention: commit.Conventional, Types: []string{"feat", "fix"}},
+				style:       commit.StyleSubject,
+			}
+			require.Equal(t, tt.strategy, plan.plan.Strategy)
+			chain := ai.NewChainProvider(
+				ai.NamedProvider{Name: "openai", Provider: failingProvider{}},
+				ai.NamedProvider{Name: "ollama", Provider: tt.provider},
+			)
+
+			ctx := context.Background()
+			input, err := plan.render(ctx, chain)
+			require.NoError(t, err)
+			result, err := chain.GenerateCommitMessage(ctx, input)
+			require.NoError(t, err)
+			plan.answered(chain, result)
+			messages := plan.finalize(ctx, chain, input, result.Messages)
+			require.Equal(t, []string{tt.message}, messages)
+
+			out, err := plan.report(chain, messages[0], []string{tt.message, "feat: paginate lists"})
+			require.NoError(t, err)
+
+			var r generateReport
+			require.NoError(t, json.Unmarshal([]byte(out), &r))
+			assert.Equal(t, tt.message, r.Message)
+			assert.Equal(t, "add pagination", r.Subject)
+			assert.Equal(t, "ollama", r.Provider)
+			assert.Equal(t, "llama3.1:8b", r.Model)
+			// Summaries and the correction count as well as the message
+			assert.Equal(t, 10*tt.requests, r.TokensIn)
+			assert.Equal(t, 2*tt.requests, r.TokensOut)
+			assert.Equal(t, tt.truncated, r.Truncated)
+			assert.Equal(t, append([]string{}, tt.skipped...), r.Skipped)
+			assert.Equal(t, []string{tt.message, "feat: paginate lists"}, r.Candidates)
+			assert.Contains(t, out, `"redactions": []`)
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/AlexThuku/GitCommitAI-/internal/prompt"
+	"golang.org/x/exp/slog"
+)
+
+const anthropicVersion = "2023-06-01"
+
+var errAnthropicOverloaded = errors.New("Anthropic API is overloaded. Please try again later")
+
+// AnthropicProvider implements the Provider interface using Anthropic's Messages API
+type AnthropicProvider struct {
+	apiKey  string
+	model   string
+	baseURL string
+	client  *http.Client
+}
+
+// AnthropicRequest represents a request to Anthropic's Messages API
+type AnthropicRequest struct {
+	Model       string             `json:"model"`
+	MaxTokens   int                `json:"max_tokens"`
+	System      string             `json:"system,omitempty"`
+	Messages    []AnthropicMessage `json:"messages"`
+	Temperature float64            `json:"temperature"`
+}
+
+// AnthropicMessage represents a message in the Anthropic API request
+type AnthropicMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// AnthropicResponse represents a response from Anthropic's Messages API
+type AnthropicResponse struct {
+	Model   string `json:"model"`
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	StopReason string `json:"stop_reason"`
+	Usage      struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// NewAnthropicProvider creates a new Anthropic provider for the API at
+// baseURL, e.g. "https://api.anthropic.com"
+func NewAnthropicProvider(apiKey, model, baseURL string) *AnthropicProvider {
+	return &AnthropicProvider{
+		apiKey:  apiKey,
+		model:   model,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  &http.Client{},
+	}
+}
+
+// GenerateCommitMessage generates a commit message from the rendered prompt
+func (p *AnthropicProvider) GenerateCommitMessage(ctx context.Context, input prompt.Prompt) (Result, error) {
+	if input.User == "" {
+		return Result{}, errors.New("empty prompt provided")
+	}
+
+	if p.apiKey == "" {
+		return Result{}, errors.New("Anthropic API key is not set")
+	}
+
+	reqBody := AnthropicRequest{
+		Model:     p.model,
+		MaxTokens: 1024,
+		System:    input.System,
+		Messages: []AnthropicMessage{
+			{
+				Role:    "user",
+				Content: input.User,
+			},
+		},
+		// Anthropic accepts temperatures up to 1, below what
+		// GenerateCandidates may ask for
+		Temperature: min(max(temperature(input), 0), 1),
+	}
+
+	reqJSON, err := json.Marshal(reqBody)
+	if err != nil {
+		return Result{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/v1/messages", bytes.NewBuffer(reqJSON))
+	if err != nil {
+		return Result{}, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-api-key", p.apiKey)
+	req.Header.Set("anthropic-version", anthropicVersion)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return Result{}, fmt.Errorf("Anthropic API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Read the full response body
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to read API response: %w", err)
+	}
+
+	// Handle overload, which Anthropic reports with a non-standard status,
+	// before decoding: a gateway in between may answer without JSON
+	if resp.StatusCode == 529 {
+		return Result{}, errAnthropicOverloaded
+	}
+
+	var anthropicResp AnthropicResponse
+	if resp.StatusCode != http.StatusOK {
+		if json.Unmarshal(body, &anthropicResp) == nil && anthropicResp.Error.Message != "" {
+			if anthropicResp.Error.Type == "overloaded_error" {
+				return Result{}, errAnthropicOverloaded
+			}
+			return Result{}, fmt.Errorf("Anthropic API error (%s): %s", anthropicResp.Error.Type, anthropicResp.Error.Message)
+		}
+		return Result{}, fmt.Errorf("Anthropic API error (%d): %s", resp.StatusCode, string(body))
+	}
+
+	if err := json.Unmarshal(body, &anthropicResp); err != nil {
+		return Result{}, fmt.Errorf("failed to parse API response: %w (body: %s)", err, string(body))
+	}
+
+	var message strings.Builder
+	for _, block := range anthropicResp.Content {
+		if block.Type == "text" {
+			message.WriteString(block.Text)
+		}
+	}
+
+	switch anthropicResp.StopReason {
+	case "refusal":
+		return Result{}, errors.New("Anthropic API declined to generate a commit message")
+	case "max_tokens":
+		slog.Warn("Anthropic response was truncated at the token limit")
+	}
+
+	if message.Len() == 0 {
+		return Result{}, errors.New("no response from Anthropic API")
+	}
+
+	return Result{
+		Messages: []string{strings.TrimSpace(message.String())},
+		Model:    anthropicResp.Model,
+		Usage: Usage{
+			InputTokens:  anthropicResp.Usage.InputTokens,
+			OutputTokens: anthropicResp.Usage.OutputTokens,
+		},
+	}, nil
+}
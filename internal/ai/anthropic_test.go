@@ -0,0 +1,130 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnthropicProviderSuccess(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/messages", r.URL.Path)
+		assert.Equal(t, "test-key", r.Header.Get("x-api-key"))
+		assert.Equal(t, anthropicVersion, r.Header.Get("anthropic-version"))
+
+		var req AnthropicRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "claude-test", req.Model)
+		assert.Contains(t, req.System, "Conventional Commits")
+		require.Len(t, req.Messages, 1)
+		assert.Equal(t, "user", req.Messages[0].Role)
+		assert.Contains(t, req.Messages[0].Content, "diff --git")
+		assert.NotContains(t, req.Messages[0].Content, "Conventional Commits")
+
+		fmt.Fprint(w, `{
+			"type": "message",
+			"model": "claude-test-20250101",
+			"content": [
+				{"type": "text", "text": "docs(readme): "},
+				{"type": "text", "text": "describe anthropic setup\n"}
+			],
+			"stop_reason": "end_turn",
+			"usage": {"input_tokens": 120, "output_tokens": 9}
+		}`)
+	}))
+	defer server.Close()
+
+	provider := NewAnthropicProvider("test-key", "claude-test", server.URL)
+
+	result, err := provider.GenerateCommitMessage(context.Background(), testPrompt("diff --git a/README.md b/README.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "docs(readme): describe anthropic setup", result.Message())
+	assert.Equal(t, "claude-test-20250101", result.Model)
+	assert.Equal(t, Usage{InputTokens: 120, OutputTokens: 9}, result.Usage)
+}
+
+func TestAnthropicProviderErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   string
+	}{
+		{
+			name:   "overloaded",
+			status: 529,
+			body:   `{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`,
+			want:   "Anthropic API is overloaded",
+		},
+		{
+			name:   "overloaded without JSON",
+			status: 529,
+			body:   "<html><body>Service overloaded</body></html>",
+			want:   "Anthropic API is overloaded",
+		},
+		{
+			name:   "gateway error",
+			status: http.StatusBadGateway,
+			body:   "<html><body>Bad gateway</body></html>",
+			want:   "Anthropic API error (502): <html>",
+		},
+		{
+			name:   "invalid request",
+			status: http.StatusBadRequest,
+			body:   `{"type":"error","error":{"type":"invalid_request_error","message":"max_tokens: field required"}}`,
+			want:   "Anthropic API error (invalid_request_error): max_tokens: field required",
+		},
+		{
+			name:   "refusal",
+			status: http.StatusOK,
+			body:   `{"type":"message","content":[],"stop_reason":"refusal"}`,
+			want:   "declined",
+		},
+		{
+			name:   "no text content",
+			status: http.StatusOK,
+			body:   `{"type":"message","content":[],"stop_reason":"end_turn"}`,
+			want:   "no response from Anthropic API",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer server.Close()
+
+			provider := NewAnthropicProvider("test-key", "claude-test", server.URL)
+			_, err := provider.GenerateCommitMessage(context.Background(), testPrompt("diff"))
+			assert.ErrorContains(t, err, tt.want)
+		})
+	}
+}
+
+func TestAnthropicProviderClampsTemperature(t *testing.T) {
+	var got []float64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req AnthropicRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		got = append(got, req.Temperature)
+		fmt.Fprint(w, `{"type":"message","content":[{"type":"text","text":"fix: a"}],"stop_reason":"end_turn"}`)
+	}))
+	defer server.Close()
+
+	provider := NewAnthropicProvider("test-key", "claude-test", server.URL)
+	for _, temperature := range []float64{0.7, 1.2} {
+		input := testPrompt("diff")
+		input.Temperature = temperature
+		_, err := provider.GenerateCommitMessage(context.Background(), input)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []float64{0.7, 1}, got)
+}
@@ -0,0 +1,125 @@
+package ai
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/AlexThuku/GitCommitAI-/internal/prompt"
+)
+
+// GenerateCandidates asks provider for up to n distinct commit messages.
+// Providers that implement CandidateProvider are asked once; others are
+// called again at increasing temperatures until n distinct messages are
+// found or the attempts run out.
+func GenerateCandidates(ctx context.Context, provider Provider, input prompt.Prompt, n int) (Result, error) {
+	if n < 1 {
+		n = 1
+	}
+
+	if cp, ok := provider.(CandidateProvider); ok && n > 1 {
+		result, err := cp.GenerateCandidates(ctx, input, n)
+		if err != nil {
+			return Result{}, err
+		}
+		result.Messages = Dedupe(result.Messages)
+		return result, nil
+	}
+
+	// The first attempt keeps the caller's temperature, or the provider's
+	// own setting when there is none; only the extra attempts vary it
+	base := temperature(input)
+	var result Result
+	for attempt := 0; len(result.Messages) < n && attempt < 2*n; attempt++ {
+		attemptInput := input
+		if attempt > 0 {
+			attemptInput.Temperature = min(base+0.15*float64(attempt), max(base, 1.2))
+		}
+
+		generated, err := provider.GenerateCommitMessage(ctx, attemptInput)
+		if err != nil {
+			// Keep what we have unless nothing was generated or the caller gave up
+			if len(result.Messages) == 0 || ctx.Err() != nil {
+				return Result{}, err
+			}
+			break
+		}
+		result.merge(generated)
+		result.Messages = Dedupe(result.Messages)
+	}
+	return result, nil
+}
+
+// Dedupe removes empty messages and messages that differ from an earlier one
+// only in case, whitespace or a trailing full stop
+func Dedupe(messages []string) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, m := range messages {
+		m = strings.TrimSpace(m)
+		key := normalize(m)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, m)
+	}
+	return out
+}
+
+func normalize(message string) string {
+	message = strings.ToLower(strings.Join(strings.Fields(message), " "))
+	return strings.TrimSuffix(message, ".")
+}
+
+// conventionalSubject matches a Conventional Commits subject line
+var conventionalSubject = regexp.MustCompile(`^[a-z]+(\([\w\-./ ]+\))?!?: \S`)
+
+// Score rates how well a message follows commit message conventions; higher
+// is better
+func Score(message string) int {
+	message = strings.TrimSpace(message)
+	if message == "" {
+		return 0
+	}
+
+	lines := strings.Split(message, "\n")
+	subject := strings.TrimSpace(lines[0])
+
+	score := 0
+	if conventionalSubject.MatchString(subject) {
+		score += 3
+	}
+	switch n := utf8.RuneCountInString(subject); {
+	case n <= 50:
+		score += 3
+	case n <= 72:
+		score += 1
+	default:
+		score -= 2
+	}
+	if !strings.HasSuffix(subject, ".") {
+		score++
+	}
+	// A body must be separated from the subject by a blank line
+	if len(lines) == 1 || strings.TrimSpace(lines[1]) == "" {
+		score++
+	}
+	// Models sometimes wrap the message in quotes or code fences
+	if strings.HasPrefix(subject, "\"") || strings.HasPrefix(subject, "`") {
+		score -= 2
+	}
+	return score
+}
+
+// Best returns the highest-scoring message, preferring earlier ones on ties
+func Best(messages []string) string {
+	best, bestScore := "", 0
+	for i, m := range messages {
+		if s := Score(m); i == 0 || s > bestScore {
+			best, bestScore = m, s
+		}
+	}
+	return best
+}
@@ -0,0 +1,84 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/AlexThuku/GitCommitAI-/internal/prompt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sequenceProvider returns its messages in turn, recording the temperature
+// each call was made at
+type sequenceProvider struct {
+	messages     []string
+	temperatures []float64
+}
+
+func (s *sequenceProvider) GenerateCommitMessage(ctx context.Context, input prompt.Prompt) (Result, error) {
+	s.temperatures = append(s.temperatures, input.Temperature)
+	message := s.messages[len(s.temperatures)-1]
+	return Result{Messages: []string{message}, Usage: Usage{InputTokens: 10, OutputTokens: 2}}, nil
+}
+
+func TestGenerateCandidatesRepeatsWithVariedTemperature(t *testing.T) {
+	provider := &sequenceProvider{messages: []string{
+		"feat: add chooser",
+		"Feat: add chooser.",
+		"fix: handle EOF",
+		"docs: describe candidates",
+	}}
+
+	result, err := GenerateCandidates(context.Background(), provider, testPrompt("diff"), 3)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"feat: add chooser", "fix: handle EOF", "docs: describe candidates"}, result.Messages)
+	// Duplicates are dropped, but the tokens they took are still counted
+	assert.Equal(t, Usage{InputTokens: 40, OutputTokens: 8}, result.Usage)
+
+	require.Len(t, provider.temperatures, 4)
+	// The first request leaves the temperature to the provider
+	assert.Zero(t, provider.temperatures[0])
+	assert.Greater(t, provider.temperatures[1], 0.7)
+	assert.Less(t, provider.temperatures[1], provider.temperatures[3])
+}
+
+func TestGenerateCandidatesKeepsTemperatureForOne(t *testing.T) {
+	provider := &sequenceProvider{messages: []string{"fix: a"}}
+
+	input := testPrompt("diff")
+	input.Temperature = 0.2
+	result, err := GenerateCandidates(context.Background(), provider, input, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"fix: a"}, result.Messages)
+	assert.Equal(t, []float64{0.2}, provider.temperatures)
+
+	provider = &sequenceProvider{messages: []string{"fix: a"}}
+	_, err = GenerateCandidates(context.Background(), provider, testPrompt("diff"), 1)
+	require.NoError(t, err)
+	assert.Equal(t, []float64{0}, provider.temperatures)
+}
+
+func TestGenerateCandidatesGivesUpOnDuplicates(t *testing.T) {
+	provider := &sequenceProvider{messages: []string{"fix: a", "fix: a", "fix: a", "fix: a"}}
+
+	result, err := GenerateCandidates(context.Background(), provider, testPrompt("diff"), 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"fix: a"}, result.Messages)
+	assert.Len(t, provider.temperatures, 4)
+}
+
+func TestBest(t *testing.T) {
+	messages := []string{
+		"Updated the code to handle the case where the input is empty and also refactored things.",
+		"\"fix: handle empty input\"",
+		"fix(cli): handle empty input",
+	}
+	assert.Equal(t, "fix(cli): handle empty input", Best(messages))
+	assert.Greater(t, Score("feat: add chooser\n\nLets the user pick."), Score("feat: add chooser\nLets the user pick."))
+	assert.Equal(t, "", Best(nil))
+
+	// Scored by characters: 40 characters, but 120 bytes
+	assert.Equal(t, Score("feat: "+strings.Repeat("a", 34)), Score("feat: "+strings.Repeat("添", 34)))
+}
@@ -0,0 +1,146 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/AlexThuku/GitCommitAI-/internal/prompt"
+)
+
+// NamedProvider pairs a provider with the name it is configured under
+type NamedProvider struct {
+	Name     string
+	Provider Provider
+}
+
+// AttemptError records why a single provider in a chain failed
+type AttemptError struct {
+	Provider string
+	Err      error
+}
+
+func (e *AttemptError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Provider, e.Err)
+}
+
+func (e *AttemptError) Unwrap() error {
+	return e.Err
+}
+
+// ChainError aggregates the failures of every provider in a chain
+type ChainError struct {
+	Attempts []*AttemptError
+}
+
+func (e *ChainError) Error() string {
+	if len(e.Attempts) == 0 {
+		return "no providers configured"
+	}
+
+	var b strings.Builder
+	b.WriteString("all providers failed:")
+	for _, attempt := range e.Attempts {
+		b.WriteString("\n  - ")
+		b.WriteString(attempt.Error())
+	}
+	return b.String()
+}
+
+// Unwrap exposes every attempt so errors.Is/As can inspect them
+func (e *ChainError) Unwrap() []error {
+	errs := make([]error, len(e.Attempts))
+	for i, attempt := range e.Attempts {
+		errs[i] = attempt
+	}
+	return errs
+}
+
+// ChainProvider implements the Provider interface by trying a list of
+// providers in order until one of them produces a message
+type ChainProvider struct {
+	providers []NamedProvider
+	used      string
+	model     string
+	usage     Usage
+
+	// OnFailure, if set, is called after each failed attempt that is
+	// followed by another one
+	OnFailure func(failed string, err error, next string)
+}
+
+// NewChainProvider creates a provider that falls back through providers in order
+func NewChainProvider(providers ...NamedProvider) *ChainProvider {
+	return &ChainProvider{providers: providers}
+}
+
+// GenerateCommitMessage generates a commit message from the rendered prompt
+func (c *ChainProvider) GenerateCommitMessage(ctx context.Context, input prompt.Prompt) (Result, error) {
+	return c.try(ctx, func(p Provider) (Result, error) {
+		return p.GenerateCommitMessage(ctx, input)
+	})
+}
+
+// GenerateCandidates asks the first working provider for up to n distinct
+// messages
+func (c *ChainProvider) GenerateCandidates(ctx context.Context, input prompt.Prompt, n int) (Result, error) {
+	return c.try(ctx, func(p Provider) (Result, error) {
+		return GenerateCandidates(ctx, p, input, n)
+	})
+}
+
+// StreamCommitMessage generates a commit message, passing the text to
+// onToken as it arrives from providers that can stream
+func (c *ChainProvider) StreamCommitMessage(ctx context.Context, input prompt.Prompt, onToken func(string)) (Result, error) {
+	return c.try(ctx, func(p Provider) (Result, error) {
+		return Stream(ctx, p, input, onToken)
+	})
+}
+
+// try calls generate with each provider in turn until one succeeds
+func (c *ChainProvider) try(ctx context.Context, generate func(Provider) (Result, error)) (Result, error) {
+	c.used = ""
+	chainErr := &ChainError{}
+
+	for i, np := range c.providers {
+		result, err := generate(np.Provider)
+		if err == nil {
+			c.used = np.Name
+			if result.Model != "" {
+				c.model = result.Model
+			}
+			c.usage = c.usage.Add(result.Usage)
+			return result, nil
+		}
+
+		chainErr.Attempts = append(chainErr.Attempts, &AttemptError{Provider: np.Name, Err: err})
+
+		// Don't keep trying once the caller has given up
+		if ctx.Err() != nil {
+			break
+		}
+
+		if c.OnFailure != nil && i+1 < len(c.providers) {
+			c.OnFailure(np.Name, err, c.providers[i+1].Name)
+		}
+	}
+
+	return Result{}, chainErr
+}
+
+// Used returns the name of the provider that produced the last message
+func (c *ChainProvider) Used() string {
+	return c.used
+}
+
+// Model returns the model that produced the last message whose provider
+// named it
+func (c *ChainProvider) Model() string {
+	return c.model
+}
+
+// Usage returns the tokens consumed by every successful request so far,
+// including summaries and corrections
+func (c *ChainProvider) Usage() Usage {
+	return c.usage
+}
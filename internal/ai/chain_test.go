@@ -0,0 +1,108 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/AlexThuku/GitCommitAI-/internal/prompt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubProvider struct {
+	message string
+	err     error
+	calls   int
+}
+
+func (s *stubProvider) GenerateCommitMessage(ctx context.Context, input prompt.Prompt) (Result, error) {
+	s.calls++
+	if s.err != nil {
+		return Result{}, s.err
+	}
+	return Result{Messages: []string{s.message}, Model: "stub", Usage: Usage{InputTokens: 100, OutputTokens: 10}}, nil
+}
+
+// testPrompt builds a minimal prompt around diff
+func testPrompt(diff string) prompt.Prompt {
+	return prompt.Prompt{
+		System: "Write a Conventional Commits message.",
+		User:   "Git diff:\n" + diff,
+		Diff:   diff,
+	}
+}
+
+func TestChainProviderFallsBack(t *testing.T) {
+	errBoom := errors.New("boom")
+	first := &stubProvider{err: errBoom}
+	second := &stubProvider{message: "fix: handle empty input"}
+	third := &stubProvider{message: "unused"}
+
+	chain := NewChainProvider(
+		NamedProvider{Name: "openai", Provider: first},
+		NamedProvider{Name: "local", Provider: second},
+		NamedProvider{Name: "huggingface", Provider: third},
+	)
+
+	var fallbacks []string
+	chain.OnFailure = func(failed string, err error, next string) {
+		fallbacks = append(fallbacks, failed+"->"+next)
+	}
+
+	result, err := chain.GenerateCommitMessage(context.Background(), testPrompt("diff"))
+	assert.NoError(t, err)
+	assert.Equal(t, "fix: handle empty input", result.Message())
+	assert.Equal(t, "local", chain.Used())
+	assert.Equal(t, "stub", chain.Model())
+	assert.Equal(t, []string{"openai->local"}, fallbacks)
+	assert.Equal(t, 0, third.calls)
+
+	// Usage adds up across requests
+	_, err = chain.GenerateCandidates(context.Background(), testPrompt("diff"), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, Usage{InputTokens: 200, OutputTokens: 20}, chain.Usage())
+}
+
+func TestChainProviderAggregatesErrors(t *testing.T) {
+	errRate := errors.New("rate limited")
+	errDown := errors.New("connection refused")
+
+	chain := NewChainProvider(
+		NamedProvider{Name: "huggingface", Provider: &stubProvider{err: errRate}},
+		NamedProvider{Name: "local", Provider: &stubProvider{err: errDown}},
+	)
+
+	_, err := chain.GenerateCommitMessage(context.Background(), testPrompt("diff"))
+	assert.Error(t, err)
+	assert.ErrorIs(t, err, errRate)
+	assert.ErrorIs(t, err, errDown)
+	assert.Contains(t, err.Error(), "huggingface: rate limited")
+	assert.Contains(t, err.Error(), "local: connection refused")
+	assert.Empty(t, chain.Used())
+}
+
+func TestChainProviderStopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	second := &stubProvider{message: "unused"}
+	chain := NewChainProvider(
+		NamedProvider{Name: "openai", Provider: &stubProvider{err: context.Canceled}},
+		NamedProvider{Name: "local", Provider: second},
+	)
+
+	_, err := chain.GenerateCommitMessage(ctx, testPrompt("diff"))
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 0, second.calls)
+}
+
+func TestStreamFallsBackToBlockingGeneration(t *testing.T) {
+	chain := NewChainProvider(NamedProvider{Name: "local", Provider: &stubProvider{message: "fix: a"}})
+
+	called := false
+	result, err := chain.StreamCommitMessage(context.Background(), testPrompt("diff"), func(string) { called = true })
+	require.NoError(t, err)
+	assert.Equal(t, "fix: a", result.Message())
+	assert.False(t, called)
+}
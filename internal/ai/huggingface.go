@@ -2,94 +2,181 @@ package ai
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"time"
+	"strconv"
+	"strings"
+
+	"github.com/AlexThuku/GitCommitAI-/internal/prompt"
 )
 
 const huggingFaceEndpoint = "https://api-inference.huggingface.co/models/"
 
+// Token limits for a generated message. A full message holds a body and
+// footers as well as the header, so it needs much more room.
+const (
+	huggingFaceMaxTokens     = 100
+	huggingFaceFullMaxTokens = 1024
+)
+
 // HuggingFaceProvider implements the Provider interface using Hugging Face's Inference API
 type HuggingFaceProvider struct {
 	token   string
 	modelID string
-	client  *http.Client
+	// endpoint is the URL models are appended to
+	endpoint string
+	client   *http.Client
 }
 
 // HuggingFaceRequest represents a request to Hugging Face's API
 type HuggingFaceRequest struct {
 	Inputs     string                 `json:"inputs"`
 	Parameters map[string]interface{} `json:"parameters,omitempty"`
+	Stream     bool                   `json:"stream,omitempty"`
+}
+
+// HuggingFaceStreamEvent is one event of a text-generation-inference
+// stream. The last one has the generated text and, when asked for, details.
+type HuggingFaceStreamEvent struct {
+	Token struct {
+		Text    string `json:"text"`
+		Special bool   `json:"special"`
+	} `json:"token"`
+	GeneratedText *string `json:"generated_text"`
+	Details       *struct {
+		GeneratedTokens int `json:"generated_tokens"`
+	} `json:"details"`
+	Error string `json:"error"`
 }
 
 // NewHuggingFaceProvider creates a new Hugging Face provider
 func NewHuggingFaceProvider(token, modelID string) *HuggingFaceProvider {
 	return &HuggingFaceProvider{
-		token:   token,
-		modelID: modelID,
-		client: &http.Client{
-			Timeout: 60 * time.Second, // Longer timeout for model inference
-		},
+		token:    token,
+		modelID:  modelID,
+		endpoint: huggingFaceEndpoint,
+		client:   &http.Client{},
 	}
 }
 
-// GenerateCommitMessage generates a commit message based on the diff
-func (p *HuggingFaceProvider) GenerateCommitMessage(diff string) (string, error) {
-	if diff == "" {
-		return "", errors.New("empty diff provided")
+// GenerateCommitMessage generates a commit message from the rendered prompt
+func (p *HuggingFaceProvider) GenerateCommitMessage(ctx context.Context, input prompt.Prompt) (Result, error) {
+	return p.generate(ctx, input, 1)
+}
+
+// GenerateCandidates asks for n sampled sequences in a single request
+func (p *HuggingFaceProvider) GenerateCandidates(ctx context.Context, input prompt.Prompt, n int) (Result, error) {
+	return p.generate(ctx, input, n)
+}
+
+// StreamCommitMessage generates a single sequence, passing the text to
+// onToken as a text-generation-inference server sends it. Models served
+// without streaming answer all at once.
+func (p *HuggingFaceProvider) StreamCommitMessage(ctx context.Context, input prompt.Prompt, onToken func(string)) (Result, error) {
+	resp, err := p.send(ctx, input, 1, true)
+	if err != nil {
+		return Result{}, err
 	}
+	defer resp.Body.Close()
 
-	if p.token == "" {
-		return "", errors.New("Hugging Face API token is not set")
+	if resp.StatusCode != http.StatusOK || !isEventStream(resp.Header.Get("Content-Type")) {
+		return p.parse(resp)
 	}
 
-	// Create prompt for the model to generate a conventional commit message
-	prompt := `You are a helpful assistant that generates git commit messages based on code diffs.
-Please analyze the following git diff and generate a clear, concise commit message following the Conventional Commits specification.
+	var (
+		result  = Result{Model: p.modelID}
+		message strings.Builder
+	)
+	err = readEvents(resp.Body, func(data string) error {
+		var event HuggingFaceStreamEvent
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return fmt.Errorf("failed to parse API response: %w (event: %s)", err, data)
+		}
+		if event.Error != "" {
+			return fmt.Errorf("Hugging Face API error: %s", event.Error)
+		}
+		if !event.Token.Special && event.Token.Text != "" {
+			message.WriteString(event.Token.Text)
+			onToken(event.Token.Text)
+		}
+		if event.Details != nil {
+			result.Usage.OutputTokens = event.Details.GeneratedTokens
+		}
+		if event.GeneratedText != nil {
+			return errStreamDone
+		}
+		return nil
+	})
+	if err != nil {
+		return Result{}, fmt.Errorf("Hugging Face API stream failed: %w", err)
+	}
 
-The format should be: <type>[optional scope]: <description>
+	if message.Len() == 0 {
+		return Result{}, errors.New("empty response from Hugging Face API")
+	}
+	result.Messages = []string{message.String()}
+	return result, nil
+}
+
+// generate requests n generated sequences for the prompt
+func (p *HuggingFaceProvider) generate(ctx context.Context, input prompt.Prompt, n int) (Result, error) {
+	resp, err := p.send(ctx, input, n, false)
+	if err != nil {
+		return Result{}, err
+	}
+	defer resp.Body.Close()
+	return p.parse(resp)
+}
 
-Where <type> is one of:
-- feat: A new feature
-- fix: A bug fix
-- docs: Documentation changes
-- style: Changes that don't affect code functionality (formatting, etc.)
-- refactor: Code changes that neither fix bugs nor add features
-- perf: Performance improvements
-- test: Adding or correcting tests
-- chore: Changes to build process, dependencies, etc.
+// send posts the prompt, asking for n sequences, streamed if stream is set
+func (p *HuggingFaceProvider) send(ctx context.Context, input prompt.Prompt, n int, stream bool) (*http.Response, error) {
+	if input.User == "" {
+		return nil, errors.New("empty prompt provided")
+	}
 
-The description should be concise but descriptive, written in imperative mood.
-Only output the commit message, no additional text.
+	if p.token == "" {
+		return nil, errors.New("Hugging Face API token is not set")
+	}
 
-Git diff:
-` + diff
+	maxTokens := huggingFaceMaxTokens
+	if input.Full || input.JSON {
+		maxTokens = huggingFaceFullMaxTokens
+	}
 
 	// Prepare request
 	reqBody := HuggingFaceRequest{
-		Inputs: prompt,
+		Inputs: input.Text(),
 		Parameters: map[string]interface{}{
-			"max_new_tokens":   100,
-			"temperature":      0.7,
+			"max_new_tokens":   maxTokens,
+			"temperature":      temperature(input),
 			"top_p":            0.95,
 			"do_sample":        true,
 			"return_full_text": false,
 		},
 	}
+	if n > 1 {
+		reqBody.Parameters["num_return_sequences"] = n
+	}
+	if stream {
+		reqBody.Stream = true
+		// Details include the number of generated tokens
+		reqBody.Parameters["details"] = true
+	}
 
 	reqJSON, err := json.Marshal(reqBody)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	// Create request to Hugging Face API
-	endpoint := huggingFaceEndpoint + p.modelID
-	req, err := http.NewRequest("POST", endpoint, bytes.NewBuffer(reqJSON))
+	endpoint := p.endpoint + p.modelID
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewBuffer(reqJSON))
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
@@ -98,25 +185,41 @@ Git diff:
 	// Send request
 	resp, err := p.client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("Hugging Face API request failed: %w", err)
+		return nil, fmt.Errorf("Hugging Face API request failed: %w", err)
 	}
-	defer resp.Body.Close()
+	return resp, nil
+}
+
+// parse reads a complete response. Text-generation-inference servers report
+// token counts in its headers.
+func (p *HuggingFaceProvider) parse(resp *http.Response) (Result, error) {
+	texts, err := parseHuggingFaceResponse(resp)
+	if err != nil {
+		return Result{}, err
+	}
+	in, _ := strconv.Atoi(resp.Header.Get("X-Prompt-Tokens"))
+	out, _ := strconv.Atoi(resp.Header.Get("X-Generated-Tokens"))
+	return Result{Messages: texts, Model: p.modelID, Usage: Usage{InputTokens: in, OutputTokens: out}}, nil
+}
 
+// parseHuggingFaceResponse returns the generated sequences of a response,
+// whose structure depends on the model
+func parseHuggingFaceResponse(resp *http.Response) ([]string, error) {
 	// Handle rate limiting
 	if resp.StatusCode == 429 {
-		return "", errors.New("Hugging Face API rate limit exceeded. Please try again later")
+		return nil, errors.New("Hugging Face API rate limit exceeded. Please try again later")
 	}
 
 	// Handle other error codes
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("Hugging Face API error (%d): %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("Hugging Face API error (%d): %s", resp.StatusCode, string(body))
 	}
 
 	// Read response
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read API response: %w", err)
+		return nil, fmt.Errorf("failed to read API response: %w", err)
 	}
 
 	// Parse response - the structure depends on the model
@@ -127,23 +230,26 @@ Git diff:
 		if err := json.Unmarshal(body, &singleResult); err != nil {
 			var mapResult []map[string]interface{}
 			if err := json.Unmarshal(body, &mapResult); err != nil {
-				return "", fmt.Errorf("failed to parse API response: %w (body: %s)", err, string(body))
+				return nil, fmt.Errorf("failed to parse API response: %w (body: %s)", err, string(body))
 			}
 
-			if len(mapResult) > 0 && mapResult[0]["generated_text"] != nil {
-				if text, ok := mapResult[0]["generated_text"].(string); ok {
-					return text, nil
+			var texts []string
+			for _, m := range mapResult {
+				if text, ok := m["generated_text"].(string); ok {
+					texts = append(texts, text)
 				}
 			}
-
-			return "", fmt.Errorf("unexpected API response format: %s", string(body))
+			if len(texts) == 0 {
+				return nil, fmt.Errorf("unexpected API response format: %s", string(body))
+			}
+			return texts, nil
 		}
-		return singleResult, nil
+		return []string{singleResult}, nil
 	}
 
 	if len(result) == 0 {
-		return "", errors.New("empty response from Hugging Face API")
+		return nil, errors.New("empty response from Hugging Face API")
 	}
 
-	return result[0], nil
+	return result, nil
 }
@@ -0,0 +1,78 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHuggingFaceProviderUsageHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/mistral", r.URL.Path)
+		w.Header().Set("X-Prompt-Tokens", "64")
+		w.Header().Set("X-Generated-Tokens", "6")
+		fmt.Fprint(w, `[{"generated_text":"docs: fix typo"}]`)
+	}))
+	defer server.Close()
+
+	provider := NewHuggingFaceProvider("hf-token", "mistral")
+	provider.endpoint = server.URL + "/"
+
+	result, err := provider.GenerateCommitMessage(context.Background(), testPrompt("diff"))
+	require.NoError(t, err)
+	assert.Equal(t, Result{Messages: []string{"docs: fix typo"}, Model: "mistral", Usage: Usage{InputTokens: 64, OutputTokens: 6}}, result)
+}
+
+func TestHuggingFaceProviderStreams(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req HuggingFaceRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.True(t, req.Stream)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data:{\"token\":{\"text\":\"feat: \",\"special\":false},\"generated_text\":null,\"details\":null}\n\n")
+		fmt.Fprint(w, "data:{\"token\":{\"text\":\"add streaming\",\"special\":false},\"generated_text\":null,\"details\":null}\n\n")
+		fmt.Fprint(w, "data:{\"token\":{\"text\":\"</s>\",\"special\":true},\"generated_text\":\"feat: add streaming\",\"details\":{\"generated_tokens\":3}}\n\n")
+	}))
+	defer server.Close()
+
+	provider := NewHuggingFaceProvider("hf-token", "mistral")
+	provider.endpoint = server.URL + "/"
+
+	var tokens []string
+	result, err := provider.StreamCommitMessage(context.Background(), testPrompt("diff"), func(s string) {
+		tokens = append(tokens, s)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"feat: ", "add streaming"}, tokens)
+	assert.Equal(t, "feat: add streaming", result.Message())
+	assert.Equal(t, 3, result.Usage.OutputTokens)
+}
+
+func TestHuggingFaceProviderFullTokenLimit(t *testing.T) {
+	var limits []float64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req HuggingFaceRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		limits = append(limits, req.Parameters["max_new_tokens"].(float64))
+		fmt.Fprint(w, `[{"generated_text":"docs: fix typo"}]`)
+	}))
+	defer server.Close()
+
+	provider := NewHuggingFaceProvider("hf-token", "mistral")
+	provider.endpoint = server.URL + "/"
+
+	input := testPrompt("diff")
+	_, err := provider.GenerateCommitMessage(context.Background(), input)
+	require.NoError(t, err)
+	input.Full = true
+	_, err = provider.GenerateCommitMessage(context.Background(), input)
+	require.NoError(t, err)
+	assert.Equal(t, []float64{huggingFaceMaxTokens, huggingFaceFullMaxTokens}, limits)
+}
@@ -2,12 +2,14 @@ package ai
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"time"
+
+	"github.com/AlexThuku/GitCommitAI-/internal/prompt"
 )
 
 // LocalProvider implements the Provider interface using a local FastAPI endpoint
@@ -18,66 +20,73 @@ type LocalProvider struct {
 
 // LocalRequestPayload defines the payload for the local model API
 type LocalRequestPayload struct {
-	Diff string `json:"diff"`
+	Diff   string `json:"diff"`
+	Prompt string `json:"prompt,omitempty"`
 }
 
 // LocalResponse defines the response from the local model API
 type LocalResponse struct {
 	CommitMessage string `json:"commit_message"`
-	Error         string `json:"error,omitempty"`
+	// Model optionally names the model that generated the message
+	Model string `json:"model,omitempty"`
+	Error string `json:"error,omitempty"`
 }
 
 // NewLocalProvider creates a new local model provider
 func NewLocalProvider(endpoint string) *LocalProvider {
 	return &LocalProvider{
 		endpoint: endpoint,
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		client:   &http.Client{},
 	}
 }
 
-// GenerateCommitMessage generates a commit message based on the diff
-func (p *LocalProvider) GenerateCommitMessage(diff string) (string, error) {
-	if diff == "" {
-		return "", errors.New("empty diff provided")
+// GenerateCommitMessage generates a commit message from the rendered prompt
+func (p *LocalProvider) GenerateCommitMessage(ctx context.Context, input prompt.Prompt) (Result, error) {
+	if input.Diff == "" {
+		return Result{}, errors.New("empty diff provided")
 	}
 
 	if p.endpoint == "" {
-		return "", errors.New("local endpoint URL is not set")
+		return Result{}, errors.New("local endpoint URL is not set")
+	}
+
+	// The endpoint's contract only promises to read the diff
+	if input.NeedsInstructions {
+		return Result{}, ErrDiffOnly
 	}
 
 	payload := LocalRequestPayload{
-		Diff: diff,
+		Diff:   input.Diff,
+		Prompt: input.Text(),
 	}
 
 	reqJSON, err := json.Marshal(payload)
 	if err != nil {
-		return "", err
+		return Result{}, err
 	}
 
-	req, err := http.NewRequest("POST", p.endpoint, bytes.NewBuffer(reqJSON))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint, bytes.NewBuffer(reqJSON))
 	if err != nil {
-		return "", err
+		return Result{}, err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := p.client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("local API request failed: %w", err)
+		return Result{}, fmt.Errorf("local API request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	// Read the full response body
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read API response: %w", err)
+		return Result{}, fmt.Errorf("failed to read API response: %w", err)
 	}
 
 	var localResp LocalResponse
 	if err := json.Unmarshal(body, &localResp); err != nil {
-		return "", fmt.Errorf("failed to parse API response: %w (body: %s)", err, string(body))
+		return Result{}, fmt.Errorf("failed to parse API response: %w (body: %s)", err, string(body))
 	}
 
 	if resp.StatusCode != http.StatusOK {
@@ -85,12 +94,12 @@ func (p *LocalProvider) GenerateCommitMessage(diff string) (string, error) {
 		if localResp.Error != "" {
 			errMsg = localResp.Error
 		}
-		return "", errors.New(errMsg)
+		return Result{}, errors.New(errMsg)
 	}
 
 	if localResp.CommitMessage == "" {
-		return "", errors.New("empty response from local API")
+		return Result{}, errors.New("empty response from local API")
 	}
 
-	return localResp.CommitMessage, nil
+	return Result{Messages: []string{localResp.CommitMessage}, Model: localResp.Model}, nil
 }
@@ -0,0 +1,250 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/AlexThuku/GitCommitAI-/internal/prompt"
+)
+
+// OllamaProvider implements the Provider interface using Ollama's native API
+type OllamaProvider struct {
+	endpoint  string
+	model     string
+	api       string
+	keepAlive string
+	options   map[string]interface{}
+	client    *http.Client
+}
+
+// OllamaSettings holds the optional tuning knobs for an Ollama provider
+type OllamaSettings struct {
+	// API selects the endpoint to use: "chat" (default) or "generate"
+	API string
+	// KeepAlive controls how long the model stays loaded, e.g. "5m"
+	KeepAlive string
+	// Options are passed through as model options, e.g. temperature or num_ctx
+	Options map[string]interface{}
+}
+
+// OllamaMessage represents a message in an Ollama chat request or response
+type OllamaMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// OllamaChatRequest represents a request to Ollama's /api/chat endpoint
+type OllamaChatRequest struct {
+	Model     string                 `json:"model"`
+	Messages  []OllamaMessage        `json:"messages"`
+	Stream    bool                   `json:"stream"`
+	KeepAlive string                 `json:"keep_alive,omitempty"`
+	Options   map[string]interface{} `json:"options,omitempty"`
+	Format    string                 `json:"format,omitempty"`
+}
+
+// OllamaGenerateRequest represents a request to Ollama's /api/generate endpoint
+type OllamaGenerateRequest struct {
+	Model     string                 `json:"model"`
+	System    string                 `json:"system,omitempty"`
+	Prompt    string                 `json:"prompt"`
+	Stream    bool                   `json:"stream"`
+	KeepAlive string                 `json:"keep_alive,omitempty"`
+	Options   map[string]interface{} `json:"options,omitempty"`
+	Format    string                 `json:"format,omitempty"`
+}
+
+// OllamaResponse represents one line of Ollama's NDJSON response stream. Chat
+// responses fill Message, generate responses fill Response.
+type OllamaResponse struct {
+	Model    string        `json:"model"`
+	Message  OllamaMessage `json:"message"`
+	Response string        `json:"response"`
+	Done     bool          `json:"done"`
+	Error    string        `json:"error,omitempty"`
+	// PromptEvalCount and EvalCount, sent with the last object, count the
+	// tokens of the prompt and the response
+	PromptEvalCount int `json:"prompt_eval_count,omitempty"`
+	EvalCount       int `json:"eval_count,omitempty"`
+}
+
+// NewOllamaProvider creates a new Ollama provider
+func NewOllamaProvider(endpoint, model string, settings OllamaSettings) *OllamaProvider {
+	api := settings.API
+	if api == "" {
+		api = "chat"
+	}
+
+	options := map[string]interface{}{"temperature": defaultTemperature}
+	for k, v := range settings.Options {
+		options[k] = v
+	}
+
+	return &OllamaProvider{
+		endpoint:  strings.TrimRight(endpoint, "/"),
+		model:     model,
+		api:       api,
+		keepAlive: settings.KeepAlive,
+		options:   options,
+		client:    &http.Client{},
+	}
+}
+
+// requestOptions returns the model options, with the temperature overridden
+// when input asks for one
+func (p *OllamaProvider) requestOptions(input prompt.Prompt) map[string]interface{} {
+	if input.Temperature <= 0 {
+		return p.options
+	}
+
+	options := make(map[string]interface{}, len(p.options))
+	for k, v := range p.options {
+		options[k] = v
+	}
+	options["temperature"] = input.Temperature
+	return options
+}
+
+// GenerateCommitMessage generates a commit message from the rendered prompt
+func (p *OllamaProvider) GenerateCommitMessage(ctx context.Context, input prompt.Prompt) (Result, error) {
+	return p.generate(ctx, input, nil)
+}
+
+// StreamCommitMessage generates a commit message, passing the text to
+// onToken as Ollama sends it
+func (p *OllamaProvider) StreamCommitMessage(ctx context.Context, input prompt.Prompt, onToken func(string)) (Result, error) {
+	return p.generate(ctx, input, onToken)
+}
+
+// generate sends the prompt and reads the streamed response, calling
+// onToken, if set, with each piece of text
+func (p *OllamaProvider) generate(ctx context.Context, input prompt.Prompt, onToken func(string)) (Result, error) {
+	if input.User == "" {
+		return Result{}, errors.New("empty prompt provided")
+	}
+
+	if p.endpoint == "" {
+		return Result{}, errors.New("Ollama endpoint URL is not set")
+	}
+
+	if p.model == "" {
+		return Result{}, errors.New("Ollama model is not set")
+	}
+
+	// Constrain the output to valid JSON when the prompt asks for it
+	var format string
+	if input.JSON {
+		format = "json"
+	}
+
+	var reqBody interface{}
+	switch p.api {
+	case "chat":
+		reqBody = OllamaChatRequest{
+			Model:     p.model,
+			Messages:  chatMessages(input),
+			Stream:    true,
+			KeepAlive: p.keepAlive,
+			Options:   p.requestOptions(input),
+			Format:    format,
+		}
+	case "generate":
+		reqBody = OllamaGenerateRequest{
+			Model:     p.model,
+			System:    input.System,
+			Prompt:    input.User,
+			Stream:    true,
+			KeepAlive: p.keepAlive,
+			Options:   p.requestOptions(input),
+			Format:    format,
+		}
+	default:
+		return Result{}, fmt.Errorf("unsupported Ollama API: %s", p.api)
+	}
+
+	reqJSON, err := json.Marshal(reqBody)
+	if err != nil {
+		return Result{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint+"/api/"+p.api, bytes.NewBuffer(reqJSON))
+	if err != nil {
+		return Result{}, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return Result{}, fmt.Errorf("Ollama API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		var errResp OllamaResponse
+		if json.Unmarshal(body, &errResp) == nil && errResp.Error != "" {
+			return Result{}, fmt.Errorf("Ollama API error (%d): %s", resp.StatusCode, errResp.Error)
+		}
+		return Result{}, fmt.Errorf("Ollama API error (%d): %s", resp.StatusCode, string(body))
+	}
+
+	// The response is a stream of JSON objects, one per line, ending with
+	// an object that has done set
+	var (
+		message strings.Builder
+		last    OllamaResponse
+	)
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var chunk OllamaResponse
+		if err := decoder.Decode(&chunk); err != nil {
+			if err == io.EOF {
+				return Result{}, errors.New("Ollama response ended before completion")
+			}
+			return Result{}, fmt.Errorf("failed to parse API response: %w", err)
+		}
+
+		if chunk.Error != "" {
+			return Result{}, fmt.Errorf("Ollama API error: %s", chunk.Error)
+		}
+
+		token := chunk.Message.Content + chunk.Response
+		message.WriteString(token)
+		if onToken != nil && token != "" {
+			onToken(token)
+		}
+
+		if chunk.Done {
+			last = chunk
+			break
+		}
+	}
+
+	result := strings.TrimSpace(message.String())
+	if result == "" {
+		return Result{}, errors.New("empty response from Ollama API")
+	}
+
+	return Result{
+		Messages: []string{result},
+		Model:    last.Model,
+		Usage:    Usage{InputTokens: last.PromptEvalCount, OutputTokens: last.EvalCount},
+	}, nil
+}
+
+// chatMessages converts a prompt into chat messages, sending the instructions
+// as a system message when the prompt has them
+func chatMessages(input prompt.Prompt) []OllamaMessage {
+	var messages []OllamaMessage
+	if input.System != "" {
+		messages = append(messages, OllamaMessage{Role: "system", Content: input.System})
+	}
+	return append(messages, OllamaMessage{Role: "user", Content: input.User})
+}
@@ -0,0 +1,115 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOllamaProviderChat(t *testing.T) {
+	var received OllamaChatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/chat", r.URL.Path)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"feat(cli): "},"done":false}`)
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"add ollama support"},"done":false}`)
+		fmt.Fprintln(w, `{"model":"llama3","message":{"role":"assistant","content":""},"done":true,"prompt_eval_count":80,"eval_count":7}`)
+	}))
+	defer server.Close()
+
+	provider := NewOllamaProvider(server.URL+"/", "llama3.1", OllamaSettings{
+		KeepAlive: "10m",
+		Options:   map[string]interface{}{"num_ctx": 8192},
+	})
+
+	var tokens []string
+	result, err := provider.StreamCommitMessage(context.Background(), testPrompt("diff --git a/x b/x"), func(s string) {
+		tokens = append(tokens, s)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"feat(cli): ", "add ollama support"}, tokens)
+	assert.Equal(t, "feat(cli): add ollama support", result.Message())
+	assert.Equal(t, "llama3", result.Model)
+	assert.Equal(t, Usage{InputTokens: 80, OutputTokens: 7}, result.Usage)
+
+	assert.Equal(t, "llama3.1", received.Model)
+	assert.True(t, received.Stream)
+	assert.Equal(t, "10m", received.KeepAlive)
+	assert.Equal(t, 0.7, received.Options["temperature"])
+	assert.Equal(t, float64(8192), received.Options["num_ctx"])
+	require.Len(t, received.Messages, 2)
+	assert.Equal(t, "system", received.Messages[0].Role)
+	assert.Contains(t, received.Messages[1].Content, "diff --git a/x b/x")
+}
+
+func TestOllamaProviderGenerate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/generate", r.URL.Path)
+
+		var req OllamaGenerateRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Contains(t, req.System, "Conventional Commits")
+		assert.Contains(t, req.Prompt, "Git diff:")
+
+		fmt.Fprintln(w, `{"response":"fix: ","done":false}`)
+		fmt.Fprintln(w, `{"response":"close file handles","done":true}`)
+	}))
+	defer server.Close()
+
+	provider := NewOllamaProvider(server.URL, "llama3.1", OllamaSettings{API: "generate"})
+
+	result, err := provider.GenerateCommitMessage(context.Background(), testPrompt("diff"))
+	require.NoError(t, err)
+	assert.Equal(t, "fix: close file handles", result.Message())
+}
+
+func TestOllamaProviderErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		want    string
+	}{
+		{
+			name: "status error",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprint(w, `{"error":"model \"llama3.1\" not found, try pulling it first"}`)
+			},
+			want: "not found, try pulling it first",
+		},
+		{
+			name: "error mid-stream",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintln(w, `{"message":{"role":"assistant","content":"feat"},"done":false}`)
+				fmt.Fprintln(w, `{"error":"out of memory"}`)
+			},
+			want: "out of memory",
+		},
+		{
+			name: "truncated stream",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintln(w, `{"message":{"role":"assistant","content":"feat"},"done":false}`)
+			},
+			want: "ended before completion",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+
+			provider := NewOllamaProvider(server.URL, "llama3.1", OllamaSettings{})
+			_, err := provider.GenerateCommitMessage(context.Background(), testPrompt("diff"))
+			assert.ErrorContains(t, err, tt.want)
+		})
+	}
+}
@@ -2,21 +2,42 @@ package ai
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"time"
-)
+	"net/url"
+	"strings"
 
-const openAIEndpoint = "https://api.openai.com/v1/chat/completions"
+	"github.com/AlexThuku/GitCommitAI-/internal/prompt"
+)
 
-// OpenAIProvider implements the Provider interface using OpenAI's API
+// OpenAIProvider implements the Provider interface using OpenAI's API or any
+// server exposing an OpenAI-compatible chat completions endpoint
 type OpenAIProvider struct {
-	apiKey string
-	model  string
-	client *http.Client
+	apiKey   string
+	model    string
+	settings OpenAISettings
+	client   *http.Client
+}
+
+// OpenAISettings points an OpenAI provider at a compatible endpoint
+type OpenAISettings struct {
+	// BaseURL is the API root, e.g. "https://api.openai.com/v1", or
+	// "http://localhost:8000/v1" for vLLM. For Azure it is the resource
+	// endpoint, e.g. "https://my-resource.openai.azure.com".
+	BaseURL string
+	// Organization is sent as the OpenAI-Organization header when set
+	Organization string
+	// Headers are added to every request, e.g. for an inference gateway
+	Headers map[string]string
+	// AzureDeployment enables Azure OpenAI mode when set
+	AzureDeployment string
+	// AzureAPIVersion is the api-version query parameter used in Azure mode,
+	// e.g. "2024-06-01"
+	AzureAPIVersion string
 }
 
 // OpenAIRequest represents a request to OpenAI's API
@@ -24,6 +45,21 @@ type OpenAIRequest struct {
 	Model       string          `json:"model"`
 	Messages    []OpenAIMessage `json:"messages"`
 	Temperature float64         `json:"temperature"`
+	N           int             `json:"n,omitempty"`
+	// ResponseFormat enables JSON mode
+	ResponseFormat *OpenAIResponseFormat `json:"response_format,omitempty"`
+	Stream         bool                  `json:"stream,omitempty"`
+	StreamOptions  *OpenAIStreamOptions  `json:"stream_options,omitempty"`
+}
+
+// OpenAIStreamOptions asks for the token usage at the end of a stream
+type OpenAIStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+// OpenAIResponseFormat selects the format of the model's output
+type OpenAIResponseFormat struct {
+	Type string `json:"type"`
 }
 
 // OpenAIMessage represents a message in the OpenAI API request
@@ -34,97 +70,222 @@ type OpenAIMessage struct {
 
 // OpenAIResponse represents a response from OpenAI's API
 type OpenAIResponse struct {
+	Model   string `json:"model"`
 	Choices []struct {
 		Message struct {
 			Content string `json:"content"`
 		} `json:"message"`
 	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
 	Error struct {
 		Message string `json:"message"`
 	} `json:"error"`
 }
 
-// NewOpenAIProvider creates a new OpenAI provider
-func NewOpenAIProvider(apiKey, model string) *OpenAIProvider {
+// OpenAIStreamChunk is one event of a streamed chat completion. The last
+// one carries the usage, when it was asked for.
+type OpenAIStreamChunk struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Index int `json:"index"`
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+	Usage *struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+	Error struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// NewOpenAIProvider creates a new OpenAI provider. The configuration
+// supplies the defaults of settings.
+func NewOpenAIProvider(apiKey, model string, settings OpenAISettings) *OpenAIProvider {
+	settings.BaseURL = strings.TrimRight(settings.BaseURL, "/")
+
 	return &OpenAIProvider{
-		apiKey: apiKey,
-		model:  model,
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		apiKey:   apiKey,
+		model:    model,
+		settings: settings,
+		client:   &http.Client{},
+	}
+}
+
+// chatCompletionsURL returns the endpoint to send chat completion requests to
+func (p *OpenAIProvider) chatCompletionsURL() string {
+	if p.settings.AzureDeployment == "" {
+		return p.settings.BaseURL + "/chat/completions"
+	}
+
+	query := url.Values{"api-version": {p.settings.AzureAPIVersion}}
+	return fmt.Sprintf("%s/openai/deployments/%s/chat/completions?%s",
+		p.settings.BaseURL, url.PathEscape(p.settings.AzureDeployment), query.Encode())
+}
+
+// setHeaders applies authentication and any configured extra headers
+func (p *OpenAIProvider) setHeaders(req *http.Request) {
+	req.Header.Set("Content-Type", "application/json")
+
+	if p.apiKey != "" {
+		if p.settings.AzureDeployment != "" {
+			req.Header.Set("api-key", p.apiKey)
+		} else {
+			req.Header.Set("Authorization", "Bearer "+p.apiKey)
+		}
+	}
+
+	if p.settings.Organization != "" {
+		req.Header.Set("OpenAI-Organization", p.settings.Organization)
+	}
+
+	for name, value := range p.settings.Headers {
+		req.Header.Set(name, value)
+	}
+}
+
+// GenerateCommitMessage generates a commit message from the rendered prompt
+func (p *OpenAIProvider) GenerateCommitMessage(ctx context.Context, input prompt.Prompt) (Result, error) {
+	return p.complete(ctx, input, 1)
+}
+
+// GenerateCandidates asks for n alternative messages in a single request
+func (p *OpenAIProvider) GenerateCandidates(ctx context.Context, input prompt.Prompt, n int) (Result, error) {
+	return p.complete(ctx, input, n)
+}
+
+// complete requests n chat completions for the prompt
+func (p *OpenAIProvider) complete(ctx context.Context, input prompt.Prompt, n int) (Result, error) {
+	resp, err := p.send(ctx, input, n, false)
+	if err != nil {
+		return Result{}, err
 	}
+	defer resp.Body.Close()
+	return parseOpenAIResponse(resp)
 }
 
-// GenerateCommitMessage generates a commit message based on the diff
-func (p *OpenAIProvider) GenerateCommitMessage(diff string) (string, error) {
-	if diff == "" {
-		return "", errors.New("empty diff provided")
+// StreamCommitMessage requests a single completion, passing the text to
+// onToken as the server sends it
+func (p *OpenAIProvider) StreamCommitMessage(ctx context.Context, input prompt.Prompt, onToken func(string)) (Result, error) {
+	resp, err := p.send(ctx, input, 1, true)
+	if err != nil {
+		return Result{}, err
 	}
+	defer resp.Body.Close()
 
-	if p.apiKey == "" {
-		return "", errors.New("OpenAI API key is not set")
+	// Errors, and servers that can't stream, come as a single response
+	if resp.StatusCode != http.StatusOK || !isEventStream(resp.Header.Get("Content-Type")) {
+		return parseOpenAIResponse(resp)
 	}
 
-	prompt := `You are a helpful assistant that generates git commit messages based on code diffs.
-Please analyze the following git diff and generate a clear, concise commit message following the Conventional Commits specification.
+	var (
+		result  Result
+		message strings.Builder
+	)
+	err = readEvents(resp.Body, func(data string) error {
+		var chunk OpenAIStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return fmt.Errorf("failed to parse API response: %w (event: %s)", err, data)
+		}
+		if chunk.Error.Message != "" {
+			return errors.New(chunk.Error.Message)
+		}
+		if chunk.Model != "" {
+			result.Model = chunk.Model
+		}
+		if chunk.Usage != nil {
+			result.Usage = Usage{InputTokens: chunk.Usage.PromptTokens, OutputTokens: chunk.Usage.CompletionTokens}
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Index == 0 && choice.Delta.Content != "" {
+				message.WriteString(choice.Delta.Content)
+				onToken(choice.Delta.Content)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return Result{}, fmt.Errorf("OpenAI API stream failed: %w", err)
+	}
 
-The format should be: <type>[optional scope]: <description>
+	if message.Len() == 0 {
+		return Result{}, errors.New("no response from OpenAI API")
+	}
+	result.Messages = []string{message.String()}
+	return result, nil
+}
 
-Where <type> is one of:
-- feat: A new feature
-- fix: A bug fix
-- docs: Documentation changes
-- style: Changes that don't affect code functionality (formatting, etc.)
-- refactor: Code changes that neither fix bugs nor add features
-- perf: Performance improvements
-- test: Adding or correcting tests
-- chore: Changes to build process, dependencies, etc.
+// send posts a request for n chat completions of the prompt, streamed if
+// stream is set
+func (p *OpenAIProvider) send(ctx context.Context, input prompt.Prompt, n int, stream bool) (*http.Response, error) {
+	if input.User == "" {
+		return nil, errors.New("empty prompt provided")
+	}
 
-The description should be concise but descriptive, written in imperative mood.
-Do not include any explanations, just return the commit message.
+	if p.settings.BaseURL == "" {
+		return nil, errors.New("OpenAI base URL is not set")
+	}
 
-Git diff:
-` + diff
+	var messages []OpenAIMessage
+	if input.System != "" {
+		messages = append(messages, OpenAIMessage{Role: "system", Content: input.System})
+	}
+	messages = append(messages, OpenAIMessage{Role: "user", Content: input.User})
 
 	reqBody := OpenAIRequest{
-		Model: p.model,
-		Messages: []OpenAIMessage{
-			{
-				Role:    "user",
-				Content: prompt,
-			},
-		},
-		Temperature: 0.7,
+		Model:       p.model,
+		Messages:    messages,
+		Temperature: temperature(input),
+	}
+	if n > 1 {
+		reqBody.N = n
+	}
+	if input.JSON {
+		reqBody.ResponseFormat = &OpenAIResponseFormat{Type: "json_object"}
+	}
+	if stream {
+		reqBody.Stream = true
+		// Older Azure API versions reject stream options
+		if p.settings.AzureDeployment == "" {
+			reqBody.StreamOptions = &OpenAIStreamOptions{IncludeUsage: true}
+		}
 	}
 
 	reqJSON, err := json.Marshal(reqBody)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	req, err := http.NewRequest("POST", openAIEndpoint, bytes.NewBuffer(reqJSON))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.chatCompletionsURL(), bytes.NewBuffer(reqJSON))
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	p.setHeaders(req)
 
 	resp, err := p.client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("OpenAI API request failed: %w", err)
+		return nil, fmt.Errorf("OpenAI API request failed: %w", err)
 	}
-	defer resp.Body.Close()
+	return resp, nil
+}
 
+// parseOpenAIResponse reads a complete chat completions response
+func parseOpenAIResponse(resp *http.Response) (Result, error) {
 	// Read the full response body
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read API response: %w", err)
+		return Result{}, fmt.Errorf("failed to read API response: %w", err)
 	}
 
 	var openAIResp OpenAIResponse
 	if err := json.Unmarshal(body, &openAIResp); err != nil {
-		return "", fmt.Errorf("failed to parse API response: %w (body: %s)", err, string(body))
+		return Result{}, fmt.Errorf("failed to parse API response: %w (body: %s)", err, string(body))
 	}
 
 	if resp.StatusCode != http.StatusOK {
@@ -132,13 +293,22 @@ Git diff:
 		if openAIResp.Error.Message != "" {
 			errMsg = openAIResp.Error.Message
 		}
-		return "", errors.New(errMsg)
+		return Result{}, errors.New(errMsg)
 	}
 
 	if len(openAIResp.Choices) == 0 {
-		return "", errors.New("no response from OpenAI API")
+		return Result{}, errors.New("no response from OpenAI API")
 	}
 
-	message := openAIResp.Choices[0].Message.Content
-	return message, nil
+	result := Result{
+		Model: openAIResp.Model,
+		Usage: Usage{
+			InputTokens:  openAIResp.Usage.PromptTokens,
+			OutputTokens: openAIResp.Usage.CompletionTokens,
+		},
+	}
+	for _, choice := range openAIResp.Choices {
+		result.Messages = append(result.Messages, choice.Message.Content)
+	}
+	return result, nil
 }
@@ -0,0 +1,129 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const openAIChatReply = `{"model":"gpt-test-0613","choices":[{"message":{"content":"chore: bump deps"}}],"usage":{"prompt_tokens":42,"completion_tokens":5}}`
+
+func TestOpenAIProviderCompatibleEndpoint(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+		assert.Empty(t, r.Header.Get("Authorization"))
+		assert.Equal(t, "acme", r.Header.Get("OpenAI-Organization"))
+		assert.Equal(t, "team-a", r.Header.Get("X-Gateway-Team"))
+		fmt.Fprint(w, openAIChatReply)
+	}))
+	defer server.Close()
+
+	// Self-hosted servers don't need an API key
+	provider := NewOpenAIProvider("", "qwen2.5-coder", OpenAISettings{
+		BaseURL:      server.URL + "/v1/",
+		Organization: "acme",
+		Headers:      map[string]string{"X-Gateway-Team": "team-a"},
+	})
+
+	result, err := provider.GenerateCommitMessage(context.Background(), testPrompt("diff"))
+	require.NoError(t, err)
+	assert.Equal(t, "chore: bump deps", result.Message())
+}
+
+func TestOpenAIProviderAzure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/openai/deployments/commit-gpt/chat/completions", r.URL.Path)
+		assert.Equal(t, "2024-06-01", r.URL.Query().Get("api-version"))
+		assert.Equal(t, "secret", r.Header.Get("api-key"))
+		assert.Empty(t, r.Header.Get("Authorization"))
+		fmt.Fprint(w, openAIChatReply)
+	}))
+	defer server.Close()
+
+	provider := NewOpenAIProvider("secret", "gpt-4o", OpenAISettings{
+		BaseURL:         server.URL,
+		AzureDeployment: "commit-gpt",
+		AzureAPIVersion: "2024-06-01",
+	})
+
+	result, err := provider.GenerateCommitMessage(context.Background(), testPrompt("diff"))
+	require.NoError(t, err)
+	assert.Equal(t, "chore: bump deps", result.Message())
+	assert.Equal(t, "gpt-test-0613", result.Model)
+	assert.Equal(t, Usage{InputTokens: 42, OutputTokens: 5}, result.Usage)
+}
+
+func TestOpenAIProviderRequiresBaseURL(t *testing.T) {
+	provider := NewOpenAIProvider("secret", "gpt-4o", OpenAISettings{})
+
+	_, err := provider.GenerateCommitMessage(context.Background(), testPrompt("diff"))
+	assert.EqualError(t, err, "OpenAI base URL is not set")
+}
+
+func TestOpenAIProviderCandidates(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req OpenAIRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, 3, req.N)
+		fmt.Fprint(w, `{"choices":[{"message":{"content":"fix: a"}},{"message":{"content":"fix: a."}},{"message":{"content":"fix: b"}}]}`)
+	}))
+	defer server.Close()
+
+	provider := NewOpenAIProvider("", "gpt-4o", OpenAISettings{BaseURL: server.URL})
+
+	result, err := GenerateCandidates(context.Background(), provider, testPrompt("diff"), 3)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"fix: a", "fix: b"}, result.Messages)
+}
+
+func TestOpenAIProviderStreams(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req OpenAIRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.True(t, req.Stream)
+		require.NotNil(t, req.StreamOptions)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"model\":\"gpt-test\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"fix: \"}}]}\n\n")
+		fmt.Fprint(w, ": keep-alive\n\n")
+		fmt.Fprint(w, "data: {\"model\":\"gpt-test\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"close files\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"model\":\"gpt-test\",\"choices\":[],\"usage\":{\"prompt_tokens\":30,\"completion_tokens\":4}}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	provider := NewOpenAIProvider("", "gpt-test", OpenAISettings{BaseURL: server.URL})
+
+	var tokens []string
+	result, err := provider.StreamCommitMessage(context.Background(), testPrompt("diff"), func(s string) {
+		tokens = append(tokens, s)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"fix: ", "close files"}, tokens)
+	assert.Equal(t, "fix: close files", result.Message())
+	assert.Equal(t, "gpt-test", result.Model)
+	assert.Equal(t, Usage{InputTokens: 30, OutputTokens: 4}, result.Usage)
+}
+
+func TestOpenAIProviderStreamFallsBack(t *testing.T) {
+	// Servers that can't stream answer with a complete response
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, openAIChatReply)
+	}))
+	defer server.Close()
+
+	provider := NewOpenAIProvider("", "gpt-test", OpenAISettings{BaseURL: server.URL})
+
+	called := false
+	result, err := provider.StreamCommitMessage(context.Background(), testPrompt("diff"), func(string) { called = true })
+	require.NoError(t, err)
+	assert.Equal(t, "chore: bump deps", result.Message())
+	assert.False(t, called)
+}
@@ -1,6 +1,88 @@
 package ai
 
-// Provider defines the interface for AI-based commit message generation
+import (
+	"context"
+	"errors"
+
+	"github.com/AlexThuku/GitCommitAI-/internal/prompt"
+)
+
+// Provider defines the interface for AI-based commit message generation.
+// Implementations must honour ctx for cancellation and deadlines.
 type Provider interface {
-	GenerateCommitMessage(diff string) (string, error)
+	GenerateCommitMessage(ctx context.Context, input prompt.Prompt) (Result, error)
+}
+
+// ErrDiffOnly is returned by providers that may only read the diff, for
+// prompts with NeedsInstructions set, which would get the same answer as
+// the diff alone
+var ErrDiffOnly = errors.New("the provider only reads the diff, not the instructions")
+
+// CandidateProvider is implemented by providers that can return several
+// alternative messages from a single request
+type CandidateProvider interface {
+	Provider
+	GenerateCandidates(ctx context.Context, input prompt.Prompt, n int) (Result, error)
+}
+
+// StreamingProvider is implemented by providers that can send the message
+// while it is being generated
+type StreamingProvider interface {
+	Provider
+	// StreamCommitMessage works like GenerateCommitMessage, calling onToken
+	// with each piece of text as it arrives
+	StreamCommitMessage(ctx context.Context, input prompt.Prompt, onToken func(string)) (Result, error)
+}
+
+// Result is what a provider generated, with what it reported about the
+// request. Providers leave out what their API doesn't tell them.
+type Result struct {
+	// Messages are the generated texts, one per requested candidate
+	Messages []string
+	// Model is the model that answered, as the provider names it
+	Model string
+	Usage Usage
+}
+
+// Message returns the first generated text
+func (r Result) Message() string {
+	if len(r.Messages) == 0 {
+		return ""
+	}
+	return r.Messages[0]
+}
+
+// merge adds the messages and usage of other to r
+func (r *Result) merge(other Result) {
+	r.Messages = append(r.Messages, other.Messages...)
+	if other.Model != "" {
+		r.Model = other.Model
+	}
+	r.Usage = r.Usage.Add(other.Usage)
+}
+
+// Usage counts the tokens consumed by one or more requests
+type Usage struct {
+	InputTokens  int
+	OutputTokens int
+}
+
+// Add returns the sum of u and other
+func (u Usage) Add(other Usage) Usage {
+	return Usage{
+		InputTokens:  u.InputTokens + other.InputTokens,
+		OutputTokens: u.OutputTokens + other.OutputTokens,
+	}
+}
+
+// defaultTemperature is the sampling temperature providers use unless the
+// prompt asks for another
+const defaultTemperature = 0.7
+
+// temperature returns the temperature input asks for, or the default
+func temperature(input prompt.Prompt) float64 {
+	if input.Temperature > 0 {
+		return input.Temperature
+	}
+	return defaultTemperature
 }
@@ -0,0 +1,76 @@
+package ai
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/AlexThuku/GitCommitAI-/internal/prompt"
+)
+
+// Stream generates a message with provider, passing the text to onToken as
+// it arrives when provider can stream. Other providers, and a nil onToken,
+// generate the whole message at once without calling onToken.
+func Stream(ctx context.Context, provider Provider, input prompt.Prompt, onToken func(string)) (Result, error) {
+	sp, ok := provider.(StreamingProvider)
+	if !ok || onToken == nil {
+		return provider.GenerateCommitMessage(ctx, input)
+	}
+	return sp.StreamCommitMessage(ctx, input, onToken)
+}
+
+// errStreamDone is returned by an event handler to stop reading the stream
+var errStreamDone = errors.New("stream done")
+
+// readEvents reads a server-sent event stream, calling handle with the data
+// of each event. It stops at the end of the stream, at OpenAI's "[DONE]"
+// marker, or when handle returns errStreamDone.
+func readEvents(r io.Reader, handle func(data string) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var data []string
+	dispatch := func() error {
+		if len(data) == 0 {
+			return nil
+		}
+		event := strings.Join(data, "\n")
+		data = data[:0]
+		if event == "[DONE]" {
+			return errStreamDone
+		}
+		return handle(event)
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if err := dispatch(); err != nil {
+				return ignoreDone(err)
+			}
+			continue
+		}
+		if value, ok := strings.CutPrefix(line, "data:"); ok {
+			data = append(data, strings.TrimPrefix(value, " "))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return ignoreDone(dispatch())
+}
+
+func ignoreDone(err error) error {
+	if errors.Is(err, errStreamDone) {
+		return nil
+	}
+	return err
+}
+
+// isEventStream reports whether a response has the server-sent events
+// content type. Servers that can't stream answer with plain JSON instead.
+func isEventStream(contentType string) bool {
+	return strings.HasPrefix(strings.TrimSpace(contentType), "text/event-stream")
+}
@@ -0,0 +1,30 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/AlexThuku/GitCommitAI-/internal/budget"
+	"github.com/AlexThuku/GitCommitAI-/internal/prompt"
+)
+
+// SummarizeFiles asks provider to describe each file's changes on its own,
+// for diffs too large to send in a single request. progress, if set, is
+// called before each file is summarised.
+func SummarizeFiles(ctx context.Context, provider Provider, files []budget.File, progress func(i int, path string)) ([]string, error) {
+	summaries := make([]string, 0, len(files))
+	for i, f := range files {
+		if progress != nil {
+			progress(i, f.Path)
+		}
+
+		summary, err := provider.GenerateCommitMessage(ctx, prompt.Summary(f.Path, f.Text))
+		if err != nil {
+			return nil, fmt.Errorf("failed to summarise %s: %w", f.Path, err)
+		}
+
+		summaries = append(summaries, f.Path+": "+strings.Join(strings.Fields(summary.Message()), " "))
+	}
+	return summaries, nil
+}
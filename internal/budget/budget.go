@@ -0,0 +1,283 @@
+package budget
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/AlexThuku/GitCommitAI-/internal/filter"
+)
+
+// Strategy describes how a diff is fitted into a model's context window
+type Strategy string
+
+const (
+	// Full sends the whole diff
+	Full Strategy = "full"
+	// Filtered sends the diff without lockfiles and generated files
+	Filtered Strategy = "filtered"
+	// MapReduce summarises each file separately, then writes the message
+	// from the summaries
+	MapReduce Strategy = "map-reduce"
+)
+
+// charsPerToken is a conservative average for source code and English text
+const charsPerToken = 4
+
+// outputReserve is the number of tokens left free for the model's answer
+const outputReserve = 1024
+
+// minBudget is the fewest tokens given to the diff, even when the rest of
+// the prompt leaves less room than that
+const minBudget = 256
+
+// defaultContextWindow is used for models we know nothing about
+const defaultContextWindow = 8192
+
+// contextWindows maps model name prefixes to their context window in tokens.
+// More specific prefixes come first.
+var contextWindows = []struct {
+	prefix string
+	tokens int
+}{
+	{"gpt-4o", 128000},
+	{"gpt-4.1", 1000000},
+	{"gpt-4-turbo", 128000},
+	{"gpt-4", 8192},
+	{"gpt-3.5-turbo", 16385},
+	{"o1", 128000},
+	{"o3", 200000},
+	{"o4", 200000},
+	{"claude", 200000},
+	{"mistralai/mistral-7b-instruct-v0.1", 8192},
+	{"mistralai/mistral-7b-instruct", 32768},
+	{"mistralai/mixtral", 32768},
+	{"meta-llama/meta-llama-3.1", 128000},
+	{"meta-llama/meta-llama-3", 8192},
+	{"qwen/qwen2.5", 32768},
+	{"llama3.1", 128000},
+	{"llama3.2", 128000},
+	{"llama3", 8192},
+}
+
+// File is a single file's portion of a diff
+type File struct {
+	Path string
+	Text string
+}
+
+// Plan records how a diff will be sent to the model
+type Plan struct {
+	Strategy Strategy
+	// Budget is the number of tokens available for the diff
+	Budget int
+	// Tokens is the estimated size of the complete diff
+	Tokens int
+	// Files are the files that will be sent, in order
+	Files []File
+	// Dropped lists low-value files left out of the diff
+	Dropped []string
+	// Truncated lists files that were cut short to fit a single request
+	Truncated []string
+	// Overflow is set when the rest of the prompt left less than the
+	// minimum budget for the diff, so the prompt won't fit the context
+	Overflow bool
+}
+
+// EstimateTokens approximates how many tokens text will use
+func EstimateTokens(text string) int {
+	return (len(text) + charsPerToken - 1) / charsPerToken
+}
+
+// ContextWindow returns the context window of model in tokens, falling back
+// to a conservative default for unknown models
+func ContextWindow(model string) int {
+	model = strings.ToLower(model)
+	for _, w := range contextWindows {
+		if strings.HasPrefix(model, w.prefix) {
+			return w.tokens
+		}
+	}
+	return defaultContextWindow
+}
+
+// InputBudget returns how many tokens of a context window can be used for
+// the prompt while leaving room for the answer
+func InputBudget(contextWindow int) int {
+	if contextWindow <= outputReserve*2 {
+		return contextWindow / 2
+	}
+	return contextWindow - outputReserve
+}
+
+// NewPlan decides how to fit files into budget tokens. Low-value files are
+// dropped first; if that isn't enough, every remaining file is summarised
+// on its own, truncating any file that is too large even for that. A
+// budget below the minimum is raised to it and the plan marked Overflow.
+func NewPlan(files []File, budget int) Plan {
+	plan := Plan{
+		Strategy: Full,
+		Tokens:   totalTokens(files),
+		Files:    files,
+	}
+	if budget < minBudget {
+		budget = minBudget
+		plan.Overflow = true
+	}
+	plan.Budget = budget
+
+	if plan.Tokens <= budget {
+		return plan
+	}
+
+	var kept []File
+	for _, f := range files {
+		if IsLowValue(f.Path, f.Text) {
+			plan.Dropped = append(plan.Dropped, f.Path)
+			continue
+		}
+		kept = append(kept, f)
+	}
+	plan.Files = kept
+
+	if len(plan.Dropped) > 0 && totalTokens(kept) <= budget {
+		plan.Strategy = Filtered
+		return plan
+	}
+
+	plan.Strategy = MapReduce
+	plan.Files = make([]File, len(kept))
+	for i, f := range kept {
+		if EstimateTokens(f.Text) > budget {
+			f.Text = truncate(f.Text, budget)
+			plan.Truncated = append(plan.Truncated, f.Path)
+		}
+		plan.Files[i] = f
+	}
+	return plan
+}
+
+// Diff joins the planned files back into a single diff
+func (p Plan) Diff() string {
+	var b strings.Builder
+	for _, f := range p.Files {
+		b.WriteString(f.Text)
+		if !strings.HasSuffix(f.Text, "\n") {
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// Summary describes the plan in a sentence or two for the user
+func (p Plan) Summary() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Diff is ~%d tokens (budget %d)", p.Tokens, p.Budget)
+
+	switch p.Strategy {
+	case Full:
+		b.WriteString("; sending it in full.")
+	case Filtered:
+		fmt.Fprintf(&b, "; leaving out %d lockfile/generated file(s): %s.",
+			len(p.Dropped), strings.Join(p.Dropped, ", "))
+	case MapReduce:
+		if len(p.Dropped) > 0 {
+			fmt.Fprintf(&b, "; leaving out %d lockfile/generated file(s): %s",
+				len(p.Dropped), strings.Join(p.Dropped, ", "))
+		}
+		fmt.Fprintf(&b, "; summarising %d file(s) individually.", len(p.Files))
+		if len(p.Truncated) > 0 {
+			fmt.Fprintf(&b, " Truncated to fit: %s.", strings.Join(p.Truncated, ", "))
+		}
+	}
+	if p.Overflow {
+		b.WriteString(" The rest of the prompt leaves almost no room for the diff; raise max_input_tokens or shorten the prompt template.")
+	}
+	return b.String()
+}
+
+func totalTokens(files []File) int {
+	total := 0
+	for _, f := range files {
+		total += EstimateTokens(f.Text)
+	}
+	return total
+}
+
+// truncate cuts text down to roughly tokens tokens at a line boundary
+func truncate(text string, tokens int) string {
+	const marker = "\n[... diff truncated ...]\n"
+
+	limit := tokens*charsPerToken - len(marker)
+	if limit <= 0 {
+		return marker
+	}
+	if len(text) <= limit {
+		return text
+	}
+
+	cut := text[:limit]
+	if i := strings.LastIndex(cut, "\n"); i > 0 {
+		cut = cut[:i]
+	}
+	return cut + marker
+}
+
+// LowValuePaths are gitignore-style patterns for files whose content
+// rarely helps describe a change. They are excluded from prompts by default.
+var LowValuePaths = []string{
+	// Lockfiles
+	"go.sum",
+	"package-lock.json",
+	"npm-shrinkwrap.json",
+	"yarn.lock",
+	"pnpm-lock.yaml",
+	"bun.lockb",
+	"Cargo.lock",
+	"Gemfile.lock",
+	"composer.lock",
+	"poetry.lock",
+	"Pipfile.lock",
+	"uv.lock",
+	"flake.lock",
+	"mix.lock",
+	"pubspec.lock",
+	"Podfile.lock",
+
+	// Generated and minified files
+	"*.pb.go",
+	"*_pb2.py",
+	"*.pb.cc",
+	"*.pb.h",
+	"*_generated.go",
+	"*.gen.go",
+	"*.min.js",
+	"*.min.css",
+	"*.map",
+	"*.snap",
+
+	// Vendored and built files
+	"vendor/",
+	"node_modules/",
+	"dist/",
+	"third_party/",
+}
+
+// lowValue matches LowValuePaths
+var lowValue = func() *filter.Matcher {
+	m, err := filter.Compile(LowValuePaths)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}()
+
+// IsLowValue reports whether a file is a lockfile, vendored, minified or
+// generated, so that its content can be left out when space is short
+func IsLowValue(filePath, text string) bool {
+	return lowValue.Match(filePath) || generatedMarker.MatchString(text)
+}
+
+// generatedMarker matches Go's convention for marking generated files on a
+// context or added line of a diff
+var generatedMarker = regexp.MustCompile(`(?m)^[+ ]// Code generated .* DO NOT EDIT\.$`)
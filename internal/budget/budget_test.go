@@ -0,0 +1,91 @@
+package budget
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// file builds a File whose diff adds the given number of lines
+func file(path string, lines int) File {
+	return File{Path: path, Text: fileDiff(path, lines)}
+}
+
+func fileDiff(path string, lines int) string {
+	var b strings.Builder
+	b.WriteString("diff --git a/" + path + " b/" + path + "\n")
+	b.WriteString("--- a/" + path + "\n+++ b/" + path + "\n")
+	b.WriteString("@@ -0,0 +1 @@\n")
+	for i := 0; i < lines; i++ {
+		b.WriteString("+line of changed content\n")
+	}
+	return b.String()
+}
+
+func TestNewPlanFull(t *testing.T) {
+	in := []File{file("main.go", 10), file("go.sum", 10)}
+
+	plan := NewPlan(in, 10000)
+	assert.Equal(t, Full, plan.Strategy)
+	assert.Len(t, plan.Files, 2)
+	assert.Empty(t, plan.Dropped)
+}
+
+func TestNewPlanFiltered(t *testing.T) {
+	in := []File{file("main.go", 10), file("go.sum", 500), file("web/package-lock.json", 500)}
+
+	plan := NewPlan(in, 1000)
+	assert.Equal(t, Filtered, plan.Strategy)
+	assert.Equal(t, []string{"go.sum", "web/package-lock.json"}, plan.Dropped)
+	require.Len(t, plan.Files, 1)
+	assert.Equal(t, "main.go", plan.Files[0].Path)
+	assert.Contains(t, plan.Summary(), "leaving out 2 lockfile/generated file(s): go.sum, web/package-lock.json")
+}
+
+func TestNewPlanMapReduce(t *testing.T) {
+	in := []File{file("a.go", 100), file("b.go", 100), file("c.go", 2000)}
+
+	plan := NewPlan(in, 1000)
+	assert.Equal(t, MapReduce, plan.Strategy)
+	require.Len(t, plan.Files, 3)
+	assert.Equal(t, []string{"c.go"}, plan.Truncated)
+	assert.LessOrEqual(t, EstimateTokens(plan.Files[2].Text), 1000)
+	assert.Contains(t, plan.Files[2].Text, "[... diff truncated ...]")
+	assert.Contains(t, plan.Summary(), "summarising 3 file(s) individually")
+}
+
+func TestNewPlanOverflow(t *testing.T) {
+	in := []File{file("a.go", 200)}
+
+	// A prompt template larger than the context leaves a negative budget
+	plan := NewPlan(in, -500)
+	assert.True(t, plan.Overflow)
+	assert.Equal(t, minBudget, plan.Budget)
+	require.Len(t, plan.Files, 1)
+	assert.Contains(t, plan.Files[0].Text, "+line of changed content")
+	assert.Contains(t, plan.Summary(), "raise max_input_tokens")
+}
+
+func TestIsLowValue(t *testing.T) {
+	assert.True(t, IsLowValue("go.sum", ""))
+	assert.True(t, IsLowValue("api/v1/service.pb.go", ""))
+	assert.True(t, IsLowValue("static/app.min.js", ""))
+	assert.True(t, IsLowValue("vendor/github.com/x/y.go", ""))
+	assert.True(t, IsLowValue("tools/vendor/x.go", ""))
+	assert.True(t, IsLowValue("zz.go", "+// Code generated by stringer. DO NOT EDIT.\n"))
+
+	assert.False(t, IsLowValue("main.go", ""))
+	assert.False(t, IsLowValue("vendoring.go", ""))
+	assert.False(t, IsLowValue("check.go", `+	return strings.Contains(text, "Code generated") // DO NOT EDIT`))
+}
+
+func TestContextWindow(t *testing.T) {
+	assert.Equal(t, 128000, ContextWindow("gpt-4o-mini"))
+	assert.Equal(t, 8192, ContextWindow("gpt-4"))
+	assert.Equal(t, 32768, ContextWindow("mistralai/Mistral-7B-Instruct-v0.2"))
+	assert.Equal(t, defaultContextWindow, ContextWindow("some/unknown-model"))
+	assert.Equal(t, 1024, InputBudget(2048))
+	assert.Equal(t, 128000-1024, InputBudget(128000))
+}
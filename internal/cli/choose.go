@@ -0,0 +1,126 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/AlexThuku/GitCommitAI-/internal/ai"
+)
+
+// combineGuidance is appended to the candidates when combining them
+const combineGuidance = `
+# Combine the suggestions above into a single commit message.
+# Lines starting with '#' will be ignored, and an empty message aborts.
+`
+
+// ChooseCandidate lists several suggested messages and lets the user accept
+// one, edit one, combine them in the editor, or ask for more. more returns
+// further suggestions and may be nil. It returns whether a message was
+// approved and the final message.
+func ChooseCandidate(candidates []string, more func() ([]string, error), notes ...string) (bool, string) {
+	for _, note := range notes {
+		fmt.Printf("  %s\n", note)
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	listCandidates(candidates)
+	for {
+		fmt.Printf("[1-%d] accept, e<N> edit, [c]ombine, %s[r]eject? ", len(candidates), moreOption(more))
+
+		input, err := reader.ReadString('\n')
+		if err != nil && input == "" {
+			// Without input there is nobody to choose a message
+			fmt.Println()
+			if !errors.Is(err, io.EOF) {
+				fmt.Fprintf(os.Stderr, "Error reading input: %v\n", err)
+			}
+			return false, ""
+		}
+
+		input = strings.TrimSpace(strings.ToLower(input))
+
+		if n, ok := candidateNumber(input, len(candidates)); ok {
+			return true, candidates[n]
+		}
+
+		switch {
+		case input == "r" || input == "reject":
+			return false, ""
+		case input == "m" || input == "more":
+			if more == nil {
+				continue
+			}
+			extra, err := more()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error generating more suggestions: %v\n", err)
+				continue
+			}
+			candidates = ai.Dedupe(append(candidates, extra...))
+			listCandidates(candidates)
+		case input == "c" || input == "combine":
+			if approved, message, done := finishEdit(editMessage(strings.Join(candidates, "\n\n"), combineGuidance)); done {
+				return approved, message
+			}
+		case strings.HasPrefix(input, "e"):
+			n, ok := 0, true
+			if rest := strings.TrimPrefix(strings.TrimPrefix(input, "edit"), "e"); rest != "" {
+				n, ok = candidateNumber(strings.TrimSpace(rest), len(candidates))
+			}
+			if !ok {
+				fmt.Printf("No suggestion %q.\n", input)
+				continue
+			}
+			if approved, message, done := finishEdit(EditMessage(candidates[n])); done {
+				return approved, message
+			}
+		}
+	}
+}
+
+// listCandidates prints the numbered suggestions, indenting message bodies
+func listCandidates(candidates []string) {
+	fmt.Println("Suggested commit messages:")
+	for i, c := range candidates {
+		lines := strings.Split(c, "\n")
+		fmt.Printf("  %d) %s\n", i+1, lines[0])
+		for _, line := range lines[1:] {
+			fmt.Printf("     %s\n", line)
+		}
+	}
+}
+
+func moreOption(more func() ([]string, error)) string {
+	if more == nil {
+		return ""
+	}
+	return "[m]ore, "
+}
+
+// candidateNumber parses a 1-based suggestion number into an index
+func candidateNumber(input string, count int) (int, bool) {
+	n, err := strconv.Atoi(input)
+	if err != nil || n < 1 || n > count {
+		return 0, false
+	}
+	return n - 1, true
+}
+
+// finishEdit turns the result of editing into an approval decision. It
+// reports done=false when the editor could not be run, so the user can
+// choose again.
+func finishEdit(message string, err error) (approved bool, result string, done bool) {
+	switch {
+	case errors.Is(err, ErrEmptyMessage):
+		fmt.Println("Aborting due to empty commit message.")
+		return false, "", true
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "Error running editor: %v\n", err)
+		return false, "", false
+	}
+	return true, message, true
+}
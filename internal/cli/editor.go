@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/AlexThuku/GitCommitAI-/internal/git"
+	"github.com/AlexThuku/GitCommitAI-/pkg/commit"
+)
+
+// ErrEmptyMessage is returned when the user saves an empty message, which
+// aborts the commit as it does in git
+var ErrEmptyMessage = errors.New("empty commit message")
+
+// editGuidance is appended to the message being edited. Guidance is written
+// with '#', which editMessage replaces with the comment character in use.
+const editGuidance = `
+# Edit the suggested commit message above. The first line is the subject;
+# leave a blank line before the body and any trailers.
+# Lines starting with '#' will be ignored, and an empty message aborts.
+`
+
+// EditMessage opens message in the user's editor and returns the result with
+// comment lines removed
+func EditMessage(message string) (string, error) {
+	return editMessage(message, editGuidance)
+}
+
+// editMessage opens message followed by the guidance comment in the editor
+func editMessage(message, guidance string) (string, error) {
+	char := commentChar(message)
+	guidance = strings.ReplaceAll(guidance, "#", char)
+
+	tmp, err := os.CreateTemp("", "git-msg-*.txt")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(strings.TrimRight(message, "\n") + "\n" + guidance); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+
+	// Like git, run the editor through the shell so it may include arguments
+	cmd := exec.Command("sh", "-c", git.Editor()+` "$@"`, "editor", tmp.Name())
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", err
+	}
+
+	edited, err := os.ReadFile(tmp.Name())
+	if err != nil {
+		return "", err
+	}
+
+	cleaned := commit.Strip(string(edited), char)
+	if cleaned == "" {
+		return "", ErrEmptyMessage
+	}
+	return cleaned, nil
+}
+
+// autoCommentChars are the characters git chooses from, in order, when
+// core.commentChar is "auto"
+const autoCommentChars = "#;@!$%^&|:"
+
+// commentChar returns the character comment lines start with: git's
+// core.commentChar, or with "auto" the first character git would choose
+// that no line of message starts with
+func commentChar(message string) string {
+	char := git.CommentChar()
+	if char != "auto" {
+		return char
+	}
+	for _, c := range autoCommentChars {
+		used := false
+		for _, line := range strings.Split(message, "\n") {
+			if strings.HasPrefix(line, string(c)) {
+				used = true
+				break
+			}
+		}
+		if !used {
+			return string(c)
+		}
+	}
+	return commit.DefaultCommentChar
+}
@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeEditor makes EditMessage run a shell script instead of an editor
+func fakeEditor(t *testing.T, script string) {
+	path := filepath.Join(t.TempDir(), "editor.sh")
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0755))
+	t.Setenv("GIT_EDITOR", path)
+}
+
+func TestEditMessage(t *testing.T) {
+	fakeEditor(t, `printf 'fix: edited\n\nWith a body.\n# guidance\n' > "$1"`)
+	edited, err := EditMessage("fix: original")
+	require.NoError(t, err)
+	assert.Equal(t, "fix: edited\n\nWith a body.", edited)
+}
+
+func TestEditMessageSeedsSuggestion(t *testing.T) {
+	fakeEditor(t, `:`)
+	edited, err := EditMessage("fix: keep me")
+	require.NoError(t, err)
+	assert.Equal(t, "fix: keep me", edited)
+}
+
+func TestEditMessageEmptyAborts(t *testing.T) {
+	fakeEditor(t, `: > "$1"`)
+	_, err := EditMessage("fix: original")
+	assert.ErrorIs(t, err, ErrEmptyMessage)
+}
+
+func TestEditMessageCommentChar(t *testing.T) {
+	t.Setenv("GIT_CONFIG_COUNT", "1")
+	t.Setenv("GIT_CONFIG_KEY_0", "core.commentChar")
+	t.Setenv("GIT_CONFIG_VALUE_0", ";")
+	fakeEditor(t, `grep -q "^; Lines starting with ';' will be ignored" "$1" || exit 1
+printf 'fix: edited\n\n#42 stays\n; guidance\n; ------------------------ >8 ------------------------\ndiff --git a/a.go b/a.go\n' > "$1"`)
+	edited, err := EditMessage("fix: original")
+	require.NoError(t, err)
+	assert.Equal(t, "fix: edited\n\n#42 stays", edited)
+
+	// With "auto", git skips characters the message already starts lines with
+	t.Setenv("GIT_CONFIG_VALUE_0", "auto")
+	assert.Equal(t, ";", commentChar("fix: handle EOF\n\n#42 was reported twice"))
+}
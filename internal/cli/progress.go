@@ -0,0 +1,158 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// spinnerFrames are drawn in turn while waiting for the model
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// spinnerInterval is how often the spinner and elapsed time are redrawn
+const spinnerInterval = 100 * time.Millisecond
+
+// Progress shows that the model is working: a spinner with the elapsed time
+// until text arrives, then the text as it is streamed. On anything but a
+// terminal it draws nothing, so logs and pipes stay clean.
+type Progress struct {
+	w     io.Writer
+	label string
+	live  bool
+	start time.Time
+
+	mu sync.Mutex
+	// drawn is set while the spinner line is on screen
+	drawn bool
+	// streaming is set once text has been written after the spinner
+	streaming bool
+	frame     int
+
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// StartProgress starts drawing progress on w, e.g. "⠋ Generating... 2.4s"
+func StartProgress(w io.Writer, label string) *Progress {
+	return startProgress(w, label, IsTerminal(w))
+}
+
+func startProgress(w io.Writer, label string, live bool) *Progress {
+	p := &Progress{w: w, label: label, live: live, start: time.Now()}
+	if live {
+		p.stop = make(chan struct{})
+		p.done = make(chan struct{})
+		go p.spin()
+	}
+	return p
+}
+
+// Live reports whether progress is drawn, and so whether streamed text
+// would be seen
+func (p *Progress) Live() bool {
+	return p.live
+}
+
+// Token writes a piece of streamed text, replacing the spinner
+func (p *Progress) Token(text string) {
+	if !p.live {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.streaming {
+		p.clear()
+		p.streaming = true
+	}
+	fmt.Fprint(p.w, text)
+}
+
+// Write prints other output, such as a note that a provider failed, on its
+// own lines. Text streamed so far is left above it and the spinner resumes
+// until more arrives.
+func (p *Progress) Write(b []byte) (int, error) {
+	if !p.live {
+		return p.w.Write(b)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.streaming {
+		fmt.Fprintln(p.w)
+		p.streaming = false
+	}
+	p.clear()
+	return p.w.Write(b)
+}
+
+// Stop removes the spinner, ending the streamed text with a newline. It may
+// be called more than once.
+func (p *Progress) Stop() {
+	if !p.live {
+		return
+	}
+	p.stopOnce.Do(func() {
+		close(p.stop)
+		<-p.done
+
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if p.streaming {
+			fmt.Fprintf(p.w, "\n(%s)\n", Elapsed(time.Since(p.start)))
+		}
+		p.clear()
+	})
+}
+
+func (p *Progress) spin() {
+	defer close(p.done)
+	ticker := time.NewTicker(spinnerInterval)
+	defer ticker.Stop()
+	for {
+		p.mu.Lock()
+		if !p.streaming {
+			fmt.Fprintf(p.w, "\r\033[K%s %s %s", spinnerFrames[p.frame%len(spinnerFrames)], p.label, Elapsed(time.Since(p.start)))
+			p.frame++
+			p.drawn = true
+		}
+		p.mu.Unlock()
+
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// clear erases the spinner line; mu must be held
+func (p *Progress) clear() {
+	if p.drawn {
+		fmt.Fprint(p.w, "\r\033[K")
+		p.drawn = false
+	}
+}
+
+// Elapsed formats a duration for progress output, e.g. "2.4s"
+func Elapsed(d time.Duration) string {
+	return fmt.Sprintf("%.1fs", d.Seconds())
+}
+
+// IsTerminal reports whether w is a terminal
+func IsTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	return ok && isTerminal(f)
+}
+
+// isTerminal checks for a character device other than the null device,
+// which scripts often redirect to
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+	null, err := os.Stat(os.DevNull)
+	return err != nil || !os.SameFile(info, null)
+}
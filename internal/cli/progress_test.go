@@ -0,0 +1,41 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgressPassesThroughWithoutTerminal(t *testing.T) {
+	var out bytes.Buffer
+	p := StartProgress(&out, "Generating...")
+	assert.False(t, p.Live())
+
+	p.Token("feat: ")
+	_, _ = p.Write([]byte("Falling back to local...\n"))
+	p.Stop()
+	assert.Equal(t, "Falling back to local...\n", out.String())
+}
+
+func TestProgressStreamsTokens(t *testing.T) {
+	var out bytes.Buffer
+	p := startProgress(&out, "Generating...", true)
+	time.Sleep(2 * spinnerInterval)
+
+	p.Token("feat: ")
+	p.Token("add streaming")
+	p.Stop()
+	p.Stop()
+
+	text := out.String()
+	assert.Contains(t, text, "Generating...")
+	assert.Contains(t, text, "\r\033[Kfeat: add streaming\n(")
+	assert.True(t, strings.HasSuffix(text, "s)\n"))
+}
+
+func TestElapsed(t *testing.T) {
+	assert.Equal(t, "2.4s", Elapsed(2430*time.Millisecond))
+}
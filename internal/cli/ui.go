@@ -2,57 +2,96 @@ package cli
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strings"
+
+	"github.com/AlexThuku/GitCommitAI-/internal/ai"
 )
 
 // PromptForApproval asks the user to approve, edit, or reject the generated commit message
-// It returns whether the message was approved and the final message
-func PromptForApproval(message string) (bool, string) {
+// It returns whether the message was approved and the final message. Notes,
+// such as what was redacted from the diff, are shown above the message.
+// more returns further suggestions and may be nil; once there are several,
+// the user chooses between them as with ChooseCandidate.
+func PromptForApproval(message string, more func() ([]string, error), notes ...string) (bool, string) {
+	for _, note := range notes {
+		fmt.Printf("  %s\n", note)
+	}
 	fmt.Printf("Suggested commit: \"%s\"\n", message)
-	fmt.Print("[a]ccept, [e]dit, [r]eject? ")
+	choices := "[a]ccept, [e]dit, " + moreOption(more) + "[r]eject"
+	fmt.Printf("%s? ", choices)
 
 	reader := bufio.NewReader(os.Stdin)
 	for {
 		input, err := reader.ReadString('\n')
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error reading input: %v\n", err)
-			continue
+		if err != nil && input == "" {
+			// Without input there is nobody to approve the message
+			fmt.Println()
+			if !errors.Is(err, io.EOF) {
+				fmt.Fprintf(os.Stderr, "Error reading input: %v\n", err)
+			}
+			return false, ""
 		}
 
 		input = strings.TrimSpace(strings.ToLower(input))
 
-		switch input {
-		case "a", "accept":
+		switch {
+		case input == "a" || input == "accept":
 			return true, message
-		case "e", "edit":
-			return true, promptForEdit(message)
-		case "r", "reject":
+		case input == "e" || input == "edit":
+			edited, err := EditMessage(message)
+			if errors.Is(err, ErrEmptyMessage) {
+				fmt.Println("Aborting due to empty commit message.")
+				return false, ""
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error running editor: %v\n", err)
+				fmt.Printf("Please enter %s: ", choices)
+				continue
+			}
+			return true, edited
+		case input == "r" || input == "reject":
 			return false, ""
+		case (input == "m" || input == "more") && more != nil:
+			extra, err := more()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error generating more suggestions: %v\n", err)
+			} else if candidates := ai.Dedupe(append([]string{message}, extra...)); len(candidates) > 1 {
+				return ChooseCandidate(candidates, more)
+			} else {
+				fmt.Println("No new suggestions.")
+			}
+			fmt.Printf("%s? ", choices)
 		default:
-			fmt.Print("Please enter [a]ccept, [e]dit, or [r]eject: ")
+			fmt.Printf("Please enter %s: ", choices)
 		}
 	}
 }
 
-// promptForEdit allows the user to edit the message
-func promptForEdit(message string) string {
-	fmt.Println("Edit your commit message (press Enter when done):")
-	fmt.Printf("> %s\n", message) // Show current message as starting point
-	fmt.Print("> ")
+// Interactive reports whether stdin is a terminal, so the user can answer
+// prompts. It isn't in CI, in editor integrations, or when input is piped.
+func Interactive() bool {
+	return isTerminal(os.Stdin)
+}
+
+// Confirm asks a yes/no question, defaulting to no
+func Confirm(question string) bool {
+	fmt.Printf("%s [y/N] ", question)
 
 	reader := bufio.NewReader(os.Stdin)
 	input, err := reader.ReadString('\n')
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error reading input: %v\n", err)
-		return message // Return original on error
+	if err != nil && input == "" {
+		fmt.Println()
+		return false
 	}
 
-	edited := strings.TrimSpace(input)
-	if edited == "" {
-		return message // Keep original if empty
+	switch strings.TrimSpace(strings.ToLower(input)) {
+	case "y", "yes":
+		return true
+	default:
+		return false
 	}
-
-	return edited
 }
@@ -5,15 +5,30 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/AlexThuku/GitCommitAI-/internal/budget"
+	"github.com/AlexThuku/GitCommitAI-/internal/git"
+	"github.com/AlexThuku/GitCommitAI-/internal/redact"
+	"github.com/AlexThuku/GitCommitAI-/internal/scope"
+	"github.com/AlexThuku/GitCommitAI-/pkg/commit"
 	"github.com/spf13/viper"
 )
 
 // Config holds the application configuration
 type Config struct {
-	// OpenAI settings (keeping for backwards compatibility)
-	OpenAIAPIKey string `mapstructure:"openai_api_key"`
-	OpenAIModel  string `mapstructure:"openai_model"`
+	// OpenAI settings, also used for OpenAI-compatible servers and Azure OpenAI
+	OpenAIAPIKey          string            `mapstructure:"openai_api_key"`
+	OpenAIModel           string            `mapstructure:"openai_model"`
+	OpenAIBaseURL         string            `mapstructure:"openai_base_url"`
+	OpenAIOrganization    string            `mapstructure:"openai_organization"`
+	OpenAIHeaders         map[string]string `mapstructure:"openai_headers"`
+	OpenAIAzureDeployment string            `mapstructure:"openai_azure_deployment"`
+	OpenAIAzureAPIVersion string            `mapstructure:"openai_azure_api_version"`
 
 	// Hugging Face settings
 	HuggingFaceToken string `mapstructure:"huggingface_token"`
@@ -23,18 +38,207 @@ type Config struct {
 	UseLocalModel bool   `mapstructure:"use_local_model"`
 	LocalEndpoint string `mapstructure:"local_endpoint"`
 
+	// Anthropic settings
+	AnthropicAPIKey  string `mapstructure:"anthropic_api_key"`
+	AnthropicModel   string `mapstructure:"anthropic_model"`
+	AnthropicBaseURL string `mapstructure:"anthropic_base_url"`
+
+	// Ollama settings
+	OllamaEndpoint  string                 `mapstructure:"ollama_endpoint"`
+	OllamaModel     string                 `mapstructure:"ollama_model"`
+	OllamaAPI       string                 `mapstructure:"ollama_api"` // "chat" or "generate"
+	OllamaKeepAlive string                 `mapstructure:"ollama_keep_alive"`
+	OllamaOptions   map[string]interface{} `mapstructure:"ollama_options"`
+
+	// Prompt settings
+	PromptTemplate string   `mapstructure:"prompt_template"` // Path to a text/template prompt
+	CommitTypes    []string `mapstructure:"commit_types"`
+	CommitScopes   []string `mapstructure:"commit_scopes"`
+	Language       string   `mapstructure:"language"`
+	RecentCommits  int      `mapstructure:"recent_commits"` // Number of recent subjects given as context
+	MessageStyle   string   `mapstructure:"message_style"`  // "subject" or "full"
+	Commitlint     bool     `mapstructure:"commitlint"`     // Follow the repository's commitlint configuration
+	Convention     string   `mapstructure:"convention"`     // Header format, e.g. "conventional", "gitmoji" or "ticket"
+	Style          string   `mapstructure:"style"`          // "conventional" to follow the convention, "auto" to follow the history, or "custom"
+	StyleSamples   int      `mapstructure:"style_samples"`  // Number of recent messages the "auto" style learns from
+
+	// Path filters for diff content sent to the model (gitignore syntax)
+	IncludePaths []string `mapstructure:"include_paths"`
+	ExcludePaths []string `mapstructure:"exclude_paths"`
+
+	// Secret and PII redaction applied before diffs leave the machine
+	Redaction RedactionConfig `mapstructure:"redaction"`
+
+	// Scope inference from the changed paths
+	ScopeInference ScopeConfig `mapstructure:"scope_inference"`
+
+	// Ticket references taken from the branch name
+	Ticket TicketConfig `mapstructure:"ticket"`
+
+	// Context budget settings
+	MaxInputTokens int `mapstructure:"max_input_tokens"` // 0 picks a budget from the model name
+
 	// General settings
-	ModelProvider string `mapstructure:"model_provider"` // "openai", "huggingface", or "local"
+	ModelProvider string        `mapstructure:"model_provider"` // "openai", "anthropic", "huggingface", "local", or "ollama"
+	FallbackChain []string      `mapstructure:"fallback_chain"` // Providers to try, in order, if the primary fails
+	Timeout       time.Duration `mapstructure:"timeout"`        // Overall deadline for generation
+}
+
+// RedactionConfig controls how sensitive values are removed from diffs
+type RedactionConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// OnSecret decides what happens when a high-severity secret is found:
+	// "ask" (default), "block", or "redact" to send the redacted diff silently
+	OnSecret string `mapstructure:"on_secret"`
+	// EntropyThreshold flags random-looking strings; 0 disables the check
+	EntropyThreshold float64         `mapstructure:"entropy_threshold"`
+	Rules            []RedactionRule `mapstructure:"rules"`
+	// Allow lists regular expressions for values that are never redacted
+	Allow []string `mapstructure:"allow"`
+}
+
+// RedactionRule is a user-defined detector added to the built-in rules
+type RedactionRule struct {
+	Name     string `mapstructure:"name"`
+	Pattern  string `mapstructure:"pattern"`
+	Severity string `mapstructure:"severity"` // "low", "medium" (default), or "high"
+}
+
+// ScopeConfig controls how commit scopes are inferred from changed paths
+type ScopeConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// MaxScopes is the most scopes a change may span before no scope is
+	// imposed
+	MaxScopes int         `mapstructure:"max_scopes"`
+	Rules     []ScopeRule `mapstructure:"rules"`
 }
 
+// ScopeRule maps paths matching a gitignore-style pattern to a scope
+type ScopeRule struct {
+	Pattern string `mapstructure:"pattern"`
+	Scope   string `mapstructure:"scope"`
+}
+
+// TicketConfig controls how a ticket reference is taken from the branch
+// name and added to messages
+type TicketConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Patterns are regular expressions finding the ticket in the branch
+	// name; the first capturing group, if any, is the ticket
+	Patterns []string `mapstructure:"patterns"`
+	// Placement is "footer", "prefix" or "scope"
+	Placement string `mapstructure:"placement"`
+	// Footer is the token of the footer, e.g. "Refs"
+	Footer string `mapstructure:"footer"`
+}
+
+// Matchers compiles the ticket patterns, or the default ones when none are
+// configured
+func (t TicketConfig) Matchers() ([]*regexp.Regexp, error) {
+	patterns := t.Patterns
+	if len(patterns) == 0 {
+		patterns = git.DefaultTicketPatterns
+	}
+	matchers := make([]*regexp.Regexp, len(patterns))
+	for i, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ticket pattern %q: %w", pattern, err)
+		}
+		matchers[i] = re
+	}
+	return matchers, nil
+}
+
+// Resolver creates the scope resolver for the repository at root
+func (s ScopeConfig) Resolver(root string) (*scope.Resolver, error) {
+	rules := make([]scope.Rule, len(s.Rules))
+	for i, r := range s.Rules {
+		rules[i] = scope.Rule{Pattern: r.Pattern, Scope: r.Scope}
+	}
+	return scope.New(root, rules)
+}
+
+// Options compiles the redaction settings
+func (r RedactionConfig) Options() (redact.Options, error) {
+	opts := redact.Options{
+		Rules:            redact.DefaultRules(),
+		EntropyThreshold: r.EntropyThreshold,
+	}
+
+	for _, rule := range r.Rules {
+		if rule.Name == "" {
+			return opts, errors.New("redaction rules need a name")
+		}
+		severity, err := redact.ParseSeverity(rule.Severity)
+		if err != nil {
+			return opts, fmt.Errorf("redaction rule %s: %w", rule.Name, err)
+		}
+		compiled, err := redact.NewRule(rule.Name, rule.Pattern, severity)
+		if err != nil {
+			return opts, err
+		}
+		opts.Rules = append(opts.Rules, compiled)
+	}
+
+	for _, pattern := range r.Allow {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return opts, fmt.Errorf("invalid redaction allow pattern %q: %w", pattern, err)
+		}
+		opts.Allow = append(opts.Allow, re)
+	}
+
+	return opts, nil
+}
+
+// ollamaDefaultContext is the context window Ollama loads models with unless
+// num_ctx is set
+const ollamaDefaultContext = 2048
+
+// DefaultOpenAIBaseURL is the base URL of the official OpenAI API
+const DefaultOpenAIBaseURL = "https://api.openai.com/v1"
+
+// DefaultAzureAPIVersion is the api-version used for Azure OpenAI when none is configured
+const DefaultAzureAPIVersion = "2024-06-01"
+
+// DefaultAnthropicBaseURL is the base URL of the Anthropic API
+const DefaultAnthropicBaseURL = "https://api.anthropic.com"
+
+// providerNames lists every provider that can appear in model_provider or fallback_chain
+var providerNames = []string{"openai", "anthropic", "huggingface", "local", "ollama"}
+
 // Load reads config from file and environment variables
 func Load() (*Config, error) {
 	// Set default values
 	viper.SetDefault("openai_model", "gpt-4o")
+	viper.SetDefault("openai_base_url", DefaultOpenAIBaseURL)
+	viper.SetDefault("openai_azure_api_version", DefaultAzureAPIVersion)
+	viper.SetDefault("anthropic_model", "claude-sonnet-4-5")
+	viper.SetDefault("anthropic_base_url", DefaultAnthropicBaseURL)
 	viper.SetDefault("huggingface_model", "mistralai/Mistral-7B-Instruct-v0.2")
 	viper.SetDefault("model_provider", "huggingface") // Default to HuggingFace
 	viper.SetDefault("use_local_model", false)
 	viper.SetDefault("local_endpoint", "http://localhost:8000/generate")
+	viper.SetDefault("ollama_endpoint", "http://localhost:11434")
+	viper.SetDefault("ollama_model", "llama3.1")
+	viper.SetDefault("ollama_api", "chat")
+	viper.SetDefault("language", "English")
+	viper.SetDefault("recent_commits", 5)
+	viper.SetDefault("message_style", "subject")
+	viper.SetDefault("commitlint", true)
+	viper.SetDefault("convention", "conventional")
+	viper.SetDefault("style", "conventional")
+	viper.SetDefault("style_samples", 100)
+	viper.SetDefault("redaction.enabled", true)
+	viper.SetDefault("redaction.on_secret", "ask")
+	viper.SetDefault("redaction.entropy_threshold", 4.5)
+	viper.SetDefault("scope_inference.enabled", true)
+	viper.SetDefault("scope_inference.max_scopes", 3)
+	viper.SetDefault("ticket.enabled", true)
+	viper.SetDefault("ticket.placement", "footer")
+	viper.SetDefault("ticket.footer", "Refs")
+	viper.SetDefault("timeout", "60s")
 
 	// Check for config in home directory
 	home, err := os.UserHomeDir()
@@ -54,6 +258,7 @@ func Load() (*Config, error) {
 	viper.AutomaticEnv()
 	viper.SetEnvPrefix("GIT_MSG")
 	viper.BindEnv("openai_api_key")
+	viper.BindEnv("anthropic_api_key")
 	viper.BindEnv("huggingface_token")
 
 	// Read config
@@ -77,34 +282,201 @@ func Load() (*Config, error) {
 	return &config, nil
 }
 
+// Validate checks the settings every command relies on. Provider settings
+// are checked separately by ValidateProvider, as not every command uses one.
 func (c *Config) Validate() error {
+	if c.Timeout < 0 {
+		return errors.New("timeout must not be negative")
+	}
+
+	if _, err := commit.ParseStyle(c.MessageStyle); err != nil {
+		return err
+	}
+	if _, err := commit.Lookup(c.Convention); err != nil {
+		return err
+	}
+	switch c.Style {
+	case "auto", "conventional", "custom":
+	default:
+		return fmt.Errorf("invalid style: %s (expected \"auto\", \"conventional\" or \"custom\")", c.Style)
+	}
+
+	// Check environment variables as backup for credentials
+	if c.OpenAIAPIKey == "" {
+		c.OpenAIAPIKey = os.Getenv("OPENAI_API_KEY")
+	}
+	if c.AnthropicAPIKey == "" {
+		c.AnthropicAPIKey = os.Getenv("ANTHROPIC_API_KEY")
+	}
+	if c.HuggingFaceToken == "" {
+		c.HuggingFaceToken = os.Getenv("HUGGINGFACE_TOKEN")
+	}
+
+	switch c.Redaction.OnSecret {
+	case "ask", "block", "redact":
+	default:
+		return fmt.Errorf("invalid redaction.on_secret: %s (expected \"ask\", \"block\" or \"redact\")", c.Redaction.OnSecret)
+	}
+	if _, err := c.Redaction.Options(); err != nil {
+		return err
+	}
+	if _, err := c.ScopeInference.Resolver(""); err != nil {
+		return err
+	}
+	if _, err := c.Ticket.Matchers(); err != nil {
+		return err
+	}
+	if _, err := commit.ParseTicketPlacement(c.Ticket.Placement); err != nil {
+		return err
+	}
+
+	for _, name := range c.FallbackChain {
+		if !slices.Contains(providerNames, name) {
+			return fmt.Errorf("invalid provider in fallback_chain: %s", name)
+		}
+	}
+	return nil
+}
+
+// ValidateProvider checks that the selected provider has the settings it needs
+func (c *Config) ValidateProvider() error {
 	switch c.ModelProvider {
 	case "openai":
-		if c.OpenAIAPIKey == "" {
-			// Check environment variable as backup
-			apiKey := os.Getenv("OPENAI_API_KEY")
-			if apiKey != "" {
-				c.OpenAIAPIKey = apiKey
-			} else {
-				return errors.New("OpenAI API key is required when using OpenAI provider")
-			}
+		if c.OpenAIAPIKey == "" && c.OpenAIRequiresAPIKey() {
+			return errors.New("OpenAI API key is required when using OpenAI provider")
+		}
+		// Azure deployments live on the resource's own endpoint
+		if c.OpenAIAzureDeployment != "" && c.officialOpenAI() {
+			return errors.New("openai_base_url must be set to the Azure resource endpoint, e.g. https://my-resource.openai.azure.com, when openai_azure_deployment is set")
+		}
+	case "anthropic":
+		if c.AnthropicAPIKey == "" {
+			return errors.New("Anthropic API key is required when using Anthropic provider")
 		}
 	case "huggingface":
 		if c.HuggingFaceToken == "" {
-			// Check environment variable as backup
-			token := os.Getenv("HUGGINGFACE_TOKEN")
-			if token != "" {
-				c.HuggingFaceToken = token
-			} else {
-				return errors.New("Hugging Face token is required when using Hugging Face provider")
-			}
+			return errors.New("Hugging Face token is required when using Hugging Face provider")
 		}
 	case "local":
 		if c.LocalEndpoint == "" {
 			return errors.New("local endpoint URL is required when using local model")
 		}
+	case "ollama":
+		if c.OllamaEndpoint == "" {
+			return errors.New("Ollama endpoint URL is required when using Ollama provider")
+		}
+		if c.OllamaModel == "" {
+			return errors.New("Ollama model is required when using Ollama provider")
+		}
+		if c.OllamaAPI != "chat" && c.OllamaAPI != "generate" {
+			return fmt.Errorf("invalid ollama_api: %s (expected \"chat\" or \"generate\")", c.OllamaAPI)
+		}
 	default:
 		return fmt.Errorf("invalid model provider: %s", c.ModelProvider)
 	}
 	return nil
 }
+
+// OpenAIRequiresAPIKey reports whether the OpenAI settings target a service
+// that always needs an API key. Self-hosted compatible servers often don't.
+func (c *Config) OpenAIRequiresAPIKey() bool {
+	return c.OpenAIAzureDeployment != "" || c.officialOpenAI()
+}
+
+// officialOpenAI reports whether openai_base_url is the official API
+func (c *Config) officialOpenAI() bool {
+	return c.OpenAIBaseURL == "" || strings.TrimRight(c.OpenAIBaseURL, "/") == DefaultOpenAIBaseURL
+}
+
+// PrimaryModel returns the model name used by model_provider, if it has one
+func (c *Config) PrimaryModel() string {
+	switch c.ModelProvider {
+	case "openai":
+		return c.OpenAIModel
+	case "anthropic":
+		return c.AnthropicModel
+	case "huggingface":
+		return c.HuggingFaceModel
+	case "ollama":
+		return c.OllamaModel
+	}
+	return ""
+}
+
+// InputBudget returns how many tokens of prompt the primary model can accept
+func (c *Config) InputBudget() int {
+	if c.MaxInputTokens > 0 {
+		return c.MaxInputTokens
+	}
+
+	// Ollama's context window is whatever num_ctx the model is loaded with
+	if c.ModelProvider == "ollama" {
+		if numCtx, ok := intOption(c.OllamaOptions["num_ctx"]); ok && numCtx > 0 {
+			return budget.InputBudget(numCtx)
+		}
+		return budget.InputBudget(ollamaDefaultContext)
+	}
+
+	return budget.InputBudget(budget.ContextWindow(c.PrimaryModel()))
+}
+
+// intOption reads a numeric option, which YAML gives as an int, JSON as a
+// float64 and environment variables as a string
+func intOption(v interface{}) (int, bool) {
+	switch n := v.(type) {
+	case int:
+		return n, true
+	case int64:
+		return int(n), true
+	case float64:
+		return int(n), n == float64(int(n))
+	case string:
+		i, err := strconv.Atoi(strings.TrimSpace(n))
+		return i, err == nil
+	}
+	return 0, false
+}
+
+// ProviderChain returns the providers to try in order: the configured
+// model_provider followed by fallback_chain. When no fallback_chain is set,
+// the providers that have credentials configured are used as fallbacks.
+func (c *Config) ProviderChain() []string {
+	fallbacks := c.FallbackChain
+	if len(fallbacks) == 0 {
+		fallbacks = c.defaultFallbacks()
+	}
+
+	chain := []string{c.ModelProvider}
+	seen := map[string]bool{c.ModelProvider: true}
+	for _, name := range fallbacks {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		chain = append(chain, name)
+	}
+	return chain
+}
+
+// defaultFallbacks preserves the historical fallback order for configs that
+// don't declare a fallback_chain
+func (c *Config) defaultFallbacks() []string {
+	var fallbacks []string
+	switch c.ModelProvider {
+	case "huggingface":
+		if c.LocalEndpoint != "" {
+			fallbacks = append(fallbacks, "local")
+		}
+	case "local":
+		if c.HuggingFaceToken != "" {
+			fallbacks = append(fallbacks, "huggingface")
+		}
+	case "openai", "anthropic":
+		if c.HuggingFaceToken != "" {
+			fallbacks = append(fallbacks, "huggingface")
+		} else if c.LocalEndpoint != "" {
+			fallbacks = append(fallbacks, "local")
+		}
+	}
+	return fallbacks
+}
@@ -0,0 +1,33 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateProviderOpenAI(t *testing.T) {
+	cfg := &Config{ModelProvider: "openai", OpenAIBaseURL: DefaultOpenAIBaseURL}
+	assert.EqualError(t, cfg.ValidateProvider(), "OpenAI API key is required when using OpenAI provider")
+
+	// Self-hosted compatible servers don't need a key
+	cfg.OpenAIBaseURL = "http://localhost:8000/v1"
+	assert.NoError(t, cfg.ValidateProvider())
+
+	// Azure needs the resource endpoint
+	cfg = &Config{ModelProvider: "openai", OpenAIAPIKey: "key", OpenAIBaseURL: DefaultOpenAIBaseURL + "/", OpenAIAzureDeployment: "gpt"}
+	assert.ErrorContains(t, cfg.ValidateProvider(), "openai_base_url must be set to the Azure resource endpoint")
+
+	cfg.OpenAIBaseURL = "https://my-resource.openai.azure.com"
+	assert.NoError(t, cfg.ValidateProvider())
+}
+
+func TestInputBudgetOllamaNumCtx(t *testing.T) {
+	for _, numCtx := range []interface{}{8192, float64(8192), "8192"} {
+		cfg := &Config{ModelProvider: "ollama", OllamaOptions: map[string]interface{}{"num_ctx": numCtx}}
+		assert.Equal(t, 8192-1024, cfg.InputBudget(), "num_ctx %#v", numCtx)
+	}
+
+	cfg := &Config{ModelProvider: "ollama", OllamaOptions: map[string]interface{}{"num_ctx": "large"}}
+	assert.Equal(t, ollamaDefaultContext/2, cfg.InputBudget())
+}
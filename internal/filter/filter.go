@@ -0,0 +1,219 @@
+package filter
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// IgnoreFile is the name of the repository file listing paths whose content
+// should not be sent to the model
+const IgnoreFile = ".gitmsgignore"
+
+// pattern is a single compiled gitignore-style pattern
+type pattern struct {
+	negate  bool
+	dirOnly bool
+	re      *regexp.Regexp
+}
+
+// Matcher matches paths against gitignore-style patterns. As with
+// .gitignore, the last matching pattern wins and "!" negates a pattern.
+type Matcher struct {
+	patterns []pattern
+}
+
+// Compile builds a Matcher from gitignore-style patterns. Blank lines and
+// lines starting with "#" are ignored.
+func Compile(patterns []string) (*Matcher, error) {
+	m := &Matcher{}
+	for _, raw := range patterns {
+		p, ok, err := compilePattern(raw)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			m.patterns = append(m.patterns, p)
+		}
+	}
+	return m, nil
+}
+
+// Empty reports whether the matcher has no patterns
+func (m *Matcher) Empty() bool {
+	return len(m.patterns) == 0
+}
+
+// Match reports whether path, or any directory containing it, is matched
+func (m *Matcher) Match(path string) bool {
+	path = strings.TrimPrefix(path, "/")
+
+	// A pattern matching a directory also matches everything inside it
+	var dirs []string
+	for i := 0; i < len(path); i++ {
+		if path[i] == '/' {
+			dirs = append(dirs, path[:i])
+		}
+	}
+
+	matched := false
+	for _, p := range m.patterns {
+		if p.matches(path, dirs) {
+			matched = !p.negate
+		}
+	}
+	return matched
+}
+
+func (p pattern) matches(path string, dirs []string) bool {
+	if !p.dirOnly && p.re.MatchString(path) {
+		return true
+	}
+	for _, dir := range dirs {
+		if p.re.MatchString(dir) {
+			return true
+		}
+	}
+	return false
+}
+
+func compilePattern(raw string) (pattern, bool, error) {
+	var p pattern
+
+	line := trimTrailingSpace(raw)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return p, false, nil
+	}
+
+	if strings.HasPrefix(line, "!") {
+		p.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		p.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return p, false, nil
+	}
+
+	// Patterns with a slash anywhere but the end are relative to the root;
+	// others match at any depth
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	expr := globToRegexp(line)
+	if anchored {
+		expr = "^" + expr + "$"
+	} else {
+		expr = "^(?:.*/)?" + expr + "$"
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return p, false, fmt.Errorf("invalid path pattern %q: %w", raw, err)
+	}
+	p.re = re
+	return p, true, nil
+}
+
+// trimTrailingSpace removes trailing spaces unless they are escaped
+func trimTrailingSpace(s string) string {
+	for strings.HasSuffix(s, " ") && !strings.HasSuffix(s, `\ `) {
+		s = s[:len(s)-1]
+	}
+	return s
+}
+
+// globToRegexp translates a gitignore glob into a regular expression
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			// Leading or inner "**/" matches zero or more directories
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(glob):
+			i++
+			b.WriteString(regexp.QuoteMeta(string(glob[i])))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
+
+// ReadIgnoreFile returns the patterns in a .gitmsgignore file. A missing
+// file yields no patterns.
+func ReadIgnoreFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var patterns []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		patterns = append(patterns, scanner.Text())
+	}
+	return patterns, scanner.Err()
+}
+
+// Filter decides which files' content may be sent to the model
+type Filter struct {
+	include *Matcher
+	exclude *Matcher
+}
+
+// New creates a Filter. When include patterns are given only matching paths
+// are sent; exclude patterns then remove paths from that set.
+func New(include, exclude []string) (*Filter, error) {
+	inc, err := Compile(include)
+	if err != nil {
+		return nil, err
+	}
+	exc, err := Compile(exclude)
+	if err != nil {
+		return nil, err
+	}
+	return &Filter{include: inc, exclude: exc}, nil
+}
+
+// Allowed reports whether the content of path may be sent to the model
+func (f *Filter) Allowed(path string) bool {
+	if !f.include.Empty() && !f.include.Match(path) {
+		return false
+	}
+	return !f.exclude.Match(path)
+}
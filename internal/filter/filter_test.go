@@ -0,0 +1,87 @@
+package filter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatcher(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"go.sum", "go.sum", true},
+		{"go.sum", "tools/go.sum", true},
+		{"/go.sum", "tools/go.sum", false},
+		{"*.min.js", "web/static/app.min.js", true},
+		{"*.min.js", "web/static/app.js", false},
+		{"vendor/", "vendor/github.com/x/y.go", true},
+		{"vendor/", "vendor", false},
+		{"vendor/", "src/vendor/a.go", true},
+		{"docs/*.md", "docs/intro.md", true},
+		{"docs/*.md", "docs/guide/intro.md", false},
+		{"docs/*.md", "site/docs/intro.md", false},
+		{"**/gen/*.go", "gen/a.go", true},
+		{"**/gen/*.go", "api/v1/gen/a.go", true},
+		{"api/**/*.pb.go", "api/v1/svc/x.pb.go", true},
+		{"api/**/*.pb.go", "api/x.pb.go", true},
+		{"build/**", "build/out/bin", true},
+		{"file?.txt", "file1.txt", true},
+		{"file?.txt", "file10.txt", false},
+		{"[abc].txt", "b.txt", true},
+		{"[!abc].txt", "b.txt", false},
+		{`\#notes`, "#notes", true},
+		{"# comment", "# comment", false},
+	}
+
+	for _, tt := range tests {
+		m, err := Compile([]string{tt.pattern})
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, m.Match(tt.path), "pattern %q against %q", tt.pattern, tt.path)
+	}
+}
+
+func TestMatcherNegation(t *testing.T) {
+	m, err := Compile([]string{"*.lock", "!important.lock"})
+	require.NoError(t, err)
+
+	assert.True(t, m.Match("yarn.lock"))
+	assert.False(t, m.Match("important.lock"))
+	assert.False(t, m.Match("main.go"))
+}
+
+func TestFilter(t *testing.T) {
+	f, err := New([]string{"src/", "*.md"}, []string{"src/generated/"})
+	require.NoError(t, err)
+
+	assert.True(t, f.Allowed("src/main.go"))
+	assert.True(t, f.Allowed("README.md"))
+	assert.False(t, f.Allowed("src/generated/api.go"))
+	assert.False(t, f.Allowed("go.sum"))
+
+	// Without include patterns everything not excluded is allowed
+	f, err = New(nil, []string{"go.sum"})
+	require.NoError(t, err)
+	assert.True(t, f.Allowed("main.go"))
+	assert.False(t, f.Allowed("go.sum"))
+}
+
+func TestReadIgnoreFile(t *testing.T) {
+	dir := t.TempDir()
+
+	patterns, err := ReadIgnoreFile(filepath.Join(dir, IgnoreFile))
+	require.NoError(t, err)
+	assert.Empty(t, patterns)
+
+	path := filepath.Join(dir, IgnoreFile)
+	require.NoError(t, os.WriteFile(path, []byte("# lockfiles\ngo.sum\n\n*.pb.go\n"), 0644))
+
+	patterns, err = ReadIgnoreFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"# lockfiles", "go.sum", "", "*.pb.go"}, patterns)
+}
@@ -0,0 +1,58 @@
+package git
+
+import (
+	"os"
+	"regexp"
+	"strings"
+)
+
+// CurrentBranch returns the name of the branch being worked on in the
+// current worktree. During a rebase that is the branch being rebased, and
+// with a detached HEAD it is the only local branch pointing at HEAD. It is
+// empty when there is no such branch.
+func CurrentBranch() (string, error) {
+	// symbolic-ref also works in a repository without commits yet
+	if branch, err := run("symbolic-ref", "--short", "-q", "HEAD"); err == nil && branch != "" {
+		return branch, nil
+	}
+
+	// Rebases detach HEAD, but record the branch they will update. Each
+	// worktree keeps its own rebase state, which --git-path resolves.
+	for _, state := range []string{"rebase-merge/head-name", "rebase-apply/head-name"} {
+		path, err := run("rev-parse", "--git-path", state)
+		if err != nil {
+			return "", err
+		}
+		if data, err := os.ReadFile(path); err == nil {
+			if ref := strings.TrimSpace(string(data)); strings.HasPrefix(ref, "refs/heads/") {
+				return strings.TrimPrefix(ref, "refs/heads/"), nil
+			}
+		}
+	}
+
+	out, err := run("for-each-ref", "--points-at", "HEAD", "--format=%(refname:short)", "refs/heads")
+	if err != nil || out == "" || strings.Contains(out, "\n") {
+		return "", nil
+	}
+	return out, nil
+}
+
+// DefaultTicketPatterns match issue keys such as "PROJ-1234"
+var DefaultTicketPatterns = []string{`[A-Z][A-Z0-9]+-[0-9]+`}
+
+// TicketFromBranch returns the first ticket reference patterns find in
+// branch, e.g. "PROJ-1234" in "feature/PROJ-1234-add-login". A pattern
+// with a capturing group yields the group rather than the whole match.
+func TicketFromBranch(branch string, patterns []*regexp.Regexp) string {
+	for _, pattern := range patterns {
+		m := pattern.FindStringSubmatch(branch)
+		if m == nil {
+			continue
+		}
+		if len(m) > 1 && m[1] != "" {
+			return m[1]
+		}
+		return m[0]
+	}
+	return ""
+}
@@ -0,0 +1,76 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func gitRun(t *testing.T, args ...string) {
+	t.Helper()
+	args = append([]string{"-c", "user.name=t", "-c", "user.email=t@example.com"}, args...)
+	out, err := exec.Command("git", args...).CombinedOutput()
+	require.NoError(t, err, string(out))
+}
+
+func TestCurrentBranch(t *testing.T) {
+	dir := inTempRepo(t)
+	gitRun(t, "checkout", "-q", "-b", "feature/PROJ-12-login")
+
+	// Before the first commit
+	branch, err := CurrentBranch()
+	require.NoError(t, err)
+	assert.Equal(t, "feature/PROJ-12-login", branch)
+
+	gitRun(t, "commit", "-q", "--allow-empty", "-m", "chore: init")
+
+	// A detached HEAD takes the only branch pointing at it
+	gitRun(t, "checkout", "-q", "--detach")
+	branch, err = CurrentBranch()
+	require.NoError(t, err)
+	assert.Equal(t, "feature/PROJ-12-login", branch)
+
+	gitRun(t, "branch", "other")
+	branch, err = CurrentBranch()
+	require.NoError(t, err)
+	assert.Empty(t, branch)
+
+	// A rebase in progress records the branch being rebased
+	rebase := filepath.Join(dir, ".git", "rebase-merge")
+	require.NoError(t, os.MkdirAll(rebase, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(rebase, "head-name"), []byte("refs/heads/fix/OPS-7\n"), 0644))
+	branch, err = CurrentBranch()
+	require.NoError(t, err)
+	assert.Equal(t, "fix/OPS-7", branch)
+}
+
+func TestCurrentBranchWorktree(t *testing.T) {
+	dir := inTempRepo(t)
+	gitRun(t, "commit", "-q", "--allow-empty", "-m", "chore: init")
+	worktree := filepath.Join(t.TempDir(), "wt")
+	gitRun(t, "worktree", "add", "-q", "-b", "feature/WEB-3", worktree)
+
+	require.NoError(t, os.Chdir(worktree))
+	branch, err := CurrentBranch()
+	require.NoError(t, err)
+	assert.Equal(t, "feature/WEB-3", branch)
+
+	require.NoError(t, os.Chdir(dir))
+	branch, err = CurrentBranch()
+	require.NoError(t, err)
+	assert.NotEqual(t, "feature/WEB-3", branch)
+}
+
+func TestTicketFromBranch(t *testing.T) {
+	patterns := []*regexp.Regexp{regexp.MustCompile(DefaultTicketPatterns[0])}
+	assert.Equal(t, "PROJ-1234", TicketFromBranch("feature/PROJ-1234-add-login", patterns))
+	assert.Empty(t, TicketFromBranch("main", patterns))
+
+	patterns = []*regexp.Regexp{regexp.MustCompile(`^issue-(\d+)`)}
+	assert.Equal(t, "42", TicketFromBranch("issue-42-fix-login", patterns))
+}
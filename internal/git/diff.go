@@ -2,12 +2,28 @@ package git
 
 import (
 	"bytes"
+	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 )
 
+// diffFlags make git diff print a plain patch that ParseDiff can read,
+// whatever the user's color, external diff, prefix and submodule settings
+var diffFlags = []string{"--no-color", "--no-ext-diff", "--src-prefix=a/", "--dst-prefix=b/", "--submodule=short", "--find-renames"}
+
+// diff runs git diff with args after diffFlags
+func diff(args ...string) (string, error) {
+	cmd := exec.Command("git", append(append([]string{"diff"}, diffFlags...), args...)...)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
 // GetDiff returns the output of git diff for staged changes
 func GetDiff() (string, error) {
 	// Check if git is installed
@@ -16,24 +32,22 @@ func GetDiff() (string, error) {
 	}
 
 	// Get staged changes with git diff --staged
-	cmd := exec.Command("git", "diff", "--staged")
-	var out bytes.Buffer
-	cmd.Stdout = &out
-	if err := cmd.Run(); err != nil {
+	staged, err := StagedDiff()
+	if err != nil {
 		return "", err
 	}
 
 	// If no staged changes, get unstaged changes
-	if out.String() == "" {
-		cmd = exec.Command("git", "diff")
-		out.Reset()
-		cmd.Stdout = &out
-		if err := cmd.Run(); err != nil {
-			return "", err
-		}
+	if staged == "" {
+		return diff()
 	}
 
-	return out.String(), nil
+	return staged, nil
+}
+
+// StagedDiff returns only the changes staged for the next commit
+func StagedDiff() (string, error) {
+	return diff("--staged")
 }
 
 // SetCommitMessage sets the given commit message for the next commit
@@ -50,12 +64,132 @@ func SetCommitMessage(message string) error {
 	gitDir := strings.TrimSpace(out.String())
 	commitMsgPath := filepath.Join(gitDir, "COMMIT_EDITMSG")
 
-	// Write message to file safely
-	return os.WriteFile(commitMsgPath, []byte(message), 0644)
+	// Write to a temporary file and rename it into place so an interrupted
+	// run never leaves a half-written message behind
+	tmp, err := os.CreateTemp(gitDir, "COMMIT_EDITMSG.*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(message); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), commitMsgPath)
+}
+
+// emptyTree is the object name of git's empty tree, used as the parent of a
+// root commit
+const emptyTree = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+
+// AmendDiff returns the changes an amended commit will contain: the staged
+// tree compared with HEAD's parent
+func AmendDiff() (string, error) {
+	base := "HEAD^"
+	if _, err := run("rev-parse", "--verify", "--quiet", base); err != nil {
+		base = emptyTree
+	}
+
+	return diff("--staged", base)
 }
 
-// Commit performs the git commit using the prepared message
-func Commit() error {
-	cmd := exec.Command("git", "commit", "-F", ".git/COMMIT_EDITMSG")
-	return cmd.Run()
+// CommitOptions are passed through to git commit
+type CommitOptions struct {
+	Signoff  bool
+	NoVerify bool
+	Amend    bool
+	// Sign GPG-signs the commit, with SignKey if set or the default key
+	Sign    bool
+	SignKey string
+	// Author overrides the commit author, as "Name <email>"
+	Author string
+}
+
+// args returns the git commit flags for the options
+func (o CommitOptions) args() []string {
+	var args []string
+	if o.Signoff {
+		args = append(args, "--signoff")
+	}
+	if o.NoVerify {
+		args = append(args, "--no-verify")
+	}
+	if o.Amend {
+		args = append(args, "--amend")
+	}
+	if o.Sign {
+		if o.SignKey != "" {
+			args = append(args, "--gpg-sign="+o.SignKey)
+		} else {
+			args = append(args, "--gpg-sign")
+		}
+	}
+	if o.Author != "" {
+		args = append(args, "--author="+o.Author)
+	}
+	return args
+}
+
+// CommitError reports a failed git commit together with what git printed,
+// such as the output of a rejecting hook
+type CommitError struct {
+	Err    error
+	Stderr string
+}
+
+func (e *CommitError) Error() string {
+	if e.Stderr == "" {
+		return fmt.Sprintf("git commit failed: %v", e.Err)
+	}
+	return fmt.Sprintf("git commit failed: %v\n%s", e.Err, e.Stderr)
+}
+
+func (e *CommitError) Unwrap() error {
+	return e.Err
+}
+
+// Commit commits the staged changes with message. The message is passed to
+// git through a file in the repository's git directory, which also works in
+// worktrees and submodules where .git is a file.
+func Commit(message string, opts CommitOptions) error {
+	gitDir, err := run("rev-parse", "--git-dir")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(gitDir, "GIT_MSG_EDITMSG.*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(message); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	args := append([]string{"commit", "-F", tmp.Name()}, opts.args()...)
+	cmd := exec.Command("git", args...)
+	cmd.Stdout = os.Stdout
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return &CommitError{Err: err, Stderr: strings.TrimSpace(stderr.String())}
+	}
+
+	// Warnings from a successful commit are still worth seeing
+	os.Stderr.Write(stderr.Bytes())
+	return nil
 }
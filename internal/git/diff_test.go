@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGetDiff(t *testing.T) {
@@ -16,7 +17,13 @@ func TestGetDiff(t *testing.T) {
 	}
 	defer os.RemoveAll(tempDir)
 
-	// Initialize git repo
+	// Initialize git repo, restoring the working directory afterwards so
+	// other tests can find their testdata
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
 	os.Chdir(tempDir)
 	exec.Command("git", "init").Run()
 	exec.Command("git", "config", "user.email", "test@example.com").Run()
@@ -52,3 +59,54 @@ func TestGetDiff(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Contains(t, diff, "modified content")
 }
+
+func TestDiffIgnoresUserConfig(t *testing.T) {
+	configs := map[string][]string{
+		"color":          {"color.ui", "always"},
+		"external diff":  {"diff.external", "echo"},
+		"mnemonicPrefix": {"diff.mnemonicPrefix", "true"},
+		"noprefix":       {"diff.noprefix", "true"},
+		"submodule log":  {"diff.submodule", "log"},
+	}
+
+	for name, config := range configs {
+		t.Run(name, func(t *testing.T) {
+			inTempRepo(t)
+			require.NoError(t, os.WriteFile("main.go", []byte("package main\n"), 0644))
+			gitRun(t, "add", "main.go")
+			gitRun(t, "commit", "-q", "-m", "init")
+			gitRun(t, "config", config[0], config[1])
+
+			require.NoError(t, os.WriteFile("main.go", []byte("package main\n\nfunc main() {}\n"), 0644))
+			unstaged, err := GetDiff()
+			require.NoError(t, err)
+			gitRun(t, "add", "main.go")
+			// a staged submodule makes diff.submodule=log print a summary line
+			gitRun(t, "update-index", "--add", "--cacheinfo", "160000,9c528de1f2a1b2c3d4e5f60718293a4b5c6d7e8f,sub")
+			staged, err := StagedDiff()
+			require.NoError(t, err)
+			amend, err := AmendDiff()
+			require.NoError(t, err)
+
+			// the submodule is only staged, so it shows up in staged and amend
+			for _, c := range []struct {
+				diff  string
+				files int
+			}{{unstaged, 1}, {staged, 2}, {amend, 2}} {
+				files, err := ParseDiff(c.diff)
+				require.NoError(t, err, c.diff)
+				require.Len(t, files, c.files)
+				assert.Equal(t, "main.go", files[0].Path())
+				assert.Positive(t, files[0].Added())
+			}
+		})
+	}
+}
+
+func TestCommitOptionsArgs(t *testing.T) {
+	assert.Empty(t, CommitOptions{}.args())
+	assert.Equal(t,
+		[]string{"--signoff", "--no-verify", "--amend", "--gpg-sign", "--author=A <a@example.com>"},
+		CommitOptions{Signoff: true, NoVerify: true, Amend: true, Sign: true, Author: "A <a@example.com>"}.args())
+	assert.Equal(t, []string{"--gpg-sign=ABCD1234"}, CommitOptions{Sign: true, SignKey: "ABCD1234"}.args())
+}
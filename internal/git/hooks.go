@@ -0,0 +1,114 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// hookMarker identifies hook scripts installed by git-msg
+const hookMarker = "# Installed by git-msg."
+
+// chainedSuffix is appended to the name of a hook that was already present
+// when git-msg was installed; the git-msg hook runs it first
+const chainedSuffix = ".git-msg-chained"
+
+// ErrHookNotInstalled is returned when uninstalling a hook git-msg doesn't own
+var ErrHookNotInstalled = errors.New("git-msg hook is not installed")
+
+// HooksDir returns the directory git runs hooks from, honouring
+// core.hooksPath
+func HooksDir() (string, error) {
+	dir, err := run("rev-parse", "--git-path", "hooks")
+	if err != nil {
+		return "", fmt.Errorf("not a git repository: %w", err)
+	}
+	return filepath.Abs(dir)
+}
+
+// InstallHook installs the named hook to run executable with args followed
+// by the hook's arguments, e.g. "git-msg hook run <file>". An existing hook
+// not installed by git-msg is kept and run first, so both keep working. It
+// returns the path of the installed hook.
+func InstallHook(name, executable string, args ...string) (string, error) {
+	dir, err := HooksDir()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, name)
+	existing, err := os.ReadFile(path)
+	switch {
+	case err == nil && !isOwnHook(existing):
+		chained := path + chainedSuffix
+		if _, err := os.Stat(chained); err == nil {
+			return "", fmt.Errorf("cannot chain existing %s hook: %s already exists", name, chained)
+		}
+		if err := os.Rename(path, chained); err != nil {
+			return "", err
+		}
+	case err != nil && !errors.Is(err, os.ErrNotExist):
+		return "", err
+	}
+
+	if err := os.WriteFile(path, []byte(hookScript(name, executable, args)), 0755); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// UninstallHook removes a hook installed by InstallHook and restores any hook
+// it was chained to
+func UninstallHook(name string) error {
+	dir, err := HooksDir()
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(dir, name)
+	existing, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) || (err == nil && !isOwnHook(existing)) {
+		return ErrHookNotInstalled
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+
+	chained := path + chainedSuffix
+	if _, err := os.Stat(chained); err == nil {
+		return os.Rename(chained, path)
+	}
+	return nil
+}
+
+func isOwnHook(script []byte) bool {
+	return strings.Contains(string(script), hookMarker)
+}
+
+// hookScript is a POSIX shell hook that runs the chained hook, if any, and
+// then git-msg. A missing git-msg binary never blocks the commit.
+func hookScript(name, executable string, args []string) string {
+	quoted := "'" + strings.ReplaceAll(executable, "'", `'\''`) + "'"
+	// args are git-msg's own subcommands and flags, which need no quoting
+	command := strings.Join(append([]string{quoted}, args...), " ")
+	return `#!/bin/sh
+` + hookMarker + ` Remove with: git-msg hook uninstall ` + name + `
+
+chained="$0` + chainedSuffix + `"
+if [ -x "$chained" ]; then
+	"$chained" "$@" || exit $?
+fi
+
+[ -x ` + quoted + ` ] || exit 0
+exec ` + command + ` "$@"
+`
+}
@@ -0,0 +1,70 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// inTempRepo runs the test from a fresh repository
+func inTempRepo(t *testing.T) string {
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	t.Cleanup(func() { os.Chdir(wd) })
+	require.NoError(t, os.Chdir(dir))
+	require.NoError(t, exec.Command("git", "init", "-q").Run())
+	return dir
+}
+
+func TestInstallHookChainsExistingHook(t *testing.T) {
+	dir := inTempRepo(t)
+	hooks := filepath.Join(dir, ".git", "hooks")
+	require.NoError(t, os.MkdirAll(hooks, 0755))
+
+	existing := "#!/bin/sh\necho existing\n"
+	require.NoError(t, os.WriteFile(filepath.Join(hooks, "prepare-commit-msg"), []byte(existing), 0755))
+
+	path, err := InstallHook("prepare-commit-msg", "/usr/local/bin/git-msg", "hook", "run")
+	require.NoError(t, err)
+
+	script, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(script), "'/usr/local/bin/git-msg' hook run \"$@\"")
+	chained, err := os.ReadFile(path + chainedSuffix)
+	require.NoError(t, err)
+	assert.Equal(t, existing, string(chained))
+
+	// Reinstalling replaces our hook without chaining it to itself
+	_, err = InstallHook("prepare-commit-msg", "/usr/local/bin/git-msg", "hook", "run")
+	require.NoError(t, err)
+	chained, err = os.ReadFile(path + chainedSuffix)
+	require.NoError(t, err)
+	assert.Equal(t, existing, string(chained))
+
+	require.NoError(t, UninstallHook("prepare-commit-msg"))
+	restored, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, existing, string(restored))
+	assert.NoFileExists(t, path+chainedSuffix)
+
+	assert.ErrorIs(t, UninstallHook("prepare-commit-msg"), ErrHookNotInstalled)
+}
+
+func TestInstallHookRespectsHooksPath(t *testing.T) {
+	dir := inTempRepo(t)
+	require.NoError(t, exec.Command("git", "config", "core.hooksPath", "custom-hooks").Run())
+
+	path, err := InstallHook("prepare-commit-msg", "/bin/git-msg", "hook", "run")
+	require.NoError(t, err)
+
+	resolved, err := filepath.EvalSymlinks(filepath.Join(dir, "custom-hooks"))
+	require.NoError(t, err)
+	actual, err := filepath.EvalSymlinks(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Equal(t, resolved, actual)
+}
@@ -0,0 +1,432 @@
+package git
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// FileStatus describes what happened to a file in a diff
+type FileStatus string
+
+const (
+	StatusModified FileStatus = "modified"
+	StatusAdded    FileStatus = "added"
+	StatusDeleted  FileStatus = "deleted"
+	StatusRenamed  FileStatus = "renamed"
+	StatusCopied   FileStatus = "copied"
+	// StatusUnmerged is a file with merge conflicts, whose diff is kept
+	// verbatim rather than parsed into hunks
+	StatusUnmerged FileStatus = "unmerged"
+)
+
+// LineKind is the prefix character of a line in a hunk
+type LineKind byte
+
+const (
+	LineContext   LineKind = ' '
+	LineAdded     LineKind = '+'
+	LineRemoved   LineKind = '-'
+	LineNoNewline LineKind = '\\' // "\ No newline at end of file"
+)
+
+// Line is a single line of a hunk, without its prefix character
+type Line struct {
+	Kind LineKind
+	Text string
+}
+
+// Hunk is a contiguous block of changes within a file
+type Hunk struct {
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+	// Section is the optional function or heading git prints after the range
+	Section string
+	Lines   []Line
+
+	header string
+}
+
+// FileDiff is the part of a diff that concerns a single file
+type FileDiff struct {
+	OldPath string
+	NewPath string
+	Status  FileStatus
+	OldMode string
+	NewMode string
+	// Similarity is the rename or copy similarity percentage
+	Similarity int
+	Binary     bool
+	Language   string
+	Hunks      []Hunk
+
+	// header holds the "diff --git" line and extended headers verbatim,
+	// or all of an unmerged file's lines
+	header []string
+}
+
+// Path returns the file's path after the change, or before it for deletions
+func (f *FileDiff) Path() string {
+	if f.Status == StatusDeleted {
+		return f.OldPath
+	}
+	return f.NewPath
+}
+
+// ModeChanged reports whether the file mode changed
+func (f *FileDiff) ModeChanged() bool {
+	return f.OldMode != "" && f.NewMode != "" && f.OldMode != f.NewMode
+}
+
+// Added returns the number of added lines
+func (f *FileDiff) Added() int {
+	return f.count(LineAdded)
+}
+
+// Removed returns the number of removed lines
+func (f *FileDiff) Removed() int {
+	return f.count(LineRemoved)
+}
+
+func (f *FileDiff) count(kind LineKind) int {
+	n := 0
+	for _, h := range f.Hunks {
+		for _, l := range h.Lines {
+			if l.Kind == kind {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// String formats the file diff back into git's unified diff format
+func (f *FileDiff) String() string {
+	var b strings.Builder
+	for _, line := range f.header {
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	for _, h := range f.Hunks {
+		b.WriteString(h.header)
+		b.WriteByte('\n')
+		for _, l := range h.Lines {
+			b.WriteByte(byte(l.Kind))
+			b.WriteString(l.Text)
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
+
+// FormatDiff formats parsed file diffs back into a single unified diff
+func FormatDiff(files []*FileDiff) string {
+	var b strings.Builder
+	for _, f := range files {
+		b.WriteString(f.String())
+	}
+	return b.String()
+}
+
+// ParseDiff parses the output of git diff into one FileDiff per file
+func ParseDiff(diff string) ([]*FileDiff, error) {
+	if diff == "" {
+		return nil, nil
+	}
+
+	lines := strings.Split(strings.TrimSuffix(diff, "\n"), "\n")
+
+	var files []*FileDiff
+	var file *FileDiff
+	var hunk *Hunk
+	oldLeft, newLeft := 0, 0
+
+	for n, line := range lines {
+		// Hunk lines are consumed according to the counts in the hunk header
+		if hunk != nil && (oldLeft > 0 || newLeft > 0 || strings.HasPrefix(line, `\`)) {
+			if line == "" {
+				// Some tools strip the space from empty context lines
+				line = " "
+			}
+			kind := LineKind(line[0])
+			switch kind {
+			case LineContext:
+				oldLeft--
+				newLeft--
+			case LineRemoved:
+				oldLeft--
+			case LineAdded:
+				newLeft--
+			case LineNoNewline:
+			default:
+				return nil, fmt.Errorf("line %d: unexpected line in hunk: %q", n+1, line)
+			}
+			hunk.Lines = append(hunk.Lines, Line{Kind: kind, Text: line[1:]})
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			file = &FileDiff{Status: StatusModified}
+			file.OldPath, file.NewPath = parseGitHeaderPaths(strings.TrimPrefix(line, "diff --git "))
+			file.header = []string{line}
+			files = append(files, file)
+			hunk = nil
+
+		// A conflicted file is listed as "* Unmerged path <path>" in the
+		// staged diff, and shown as a combined diff in the unstaged one
+		case strings.HasPrefix(line, "* Unmerged path "):
+			path := unquotePath(strings.TrimPrefix(line, "* Unmerged path "))
+			files = append(files, &FileDiff{OldPath: path, NewPath: path, Status: StatusUnmerged, header: []string{line}})
+			file, hunk = nil, nil
+
+		case strings.HasPrefix(line, "diff --cc "), strings.HasPrefix(line, "diff --combined "):
+			_, path, _ := strings.Cut(line[len("diff --"):], " ")
+			path = unquotePath(path)
+			file = &FileDiff{OldPath: path, NewPath: path, Status: StatusUnmerged, header: []string{line}}
+			files = append(files, file)
+			hunk = nil
+
+		case file == nil:
+			return nil, fmt.Errorf("line %d: expected \"diff --git\" header, got %q", n+1, line)
+
+		case file.Status == StatusUnmerged:
+			file.header = append(file.header, line)
+
+		case strings.HasPrefix(line, "@@ "):
+			h, err := parseHunkHeader(line)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", n+1, err)
+			}
+			file.Hunks = append(file.Hunks, h)
+			hunk = &file.Hunks[len(file.Hunks)-1]
+			oldLeft, newLeft = h.OldLines, h.NewLines
+
+		case hunk != nil:
+			return nil, fmt.Errorf("line %d: unexpected line after hunk: %q", n+1, line)
+
+		default:
+			file.header = append(file.header, line)
+			parseExtendedHeader(file, line)
+		}
+	}
+
+	for _, f := range files {
+		f.Language = LanguageFor(f.Path())
+	}
+
+	return files, nil
+}
+
+// parseExtendedHeader records what an extended header line says about file
+func parseExtendedHeader(file *FileDiff, line string) {
+	switch {
+	case strings.HasPrefix(line, "new file mode "):
+		file.Status = StatusAdded
+		file.NewMode = strings.TrimPrefix(line, "new file mode ")
+	case strings.HasPrefix(line, "deleted file mode "):
+		file.Status = StatusDeleted
+		file.OldMode = strings.TrimPrefix(line, "deleted file mode ")
+	case strings.HasPrefix(line, "old mode "):
+		file.OldMode = strings.TrimPrefix(line, "old mode ")
+	case strings.HasPrefix(line, "new mode "):
+		file.NewMode = strings.TrimPrefix(line, "new mode ")
+	case strings.HasPrefix(line, "rename from "):
+		file.Status = StatusRenamed
+		file.OldPath = unquotePath(strings.TrimPrefix(line, "rename from "))
+	case strings.HasPrefix(line, "rename to "):
+		file.NewPath = unquotePath(strings.TrimPrefix(line, "rename to "))
+	case strings.HasPrefix(line, "copy from "):
+		file.Status = StatusCopied
+		file.OldPath = unquotePath(strings.TrimPrefix(line, "copy from "))
+	case strings.HasPrefix(line, "copy to "):
+		file.NewPath = unquotePath(strings.TrimPrefix(line, "copy to "))
+	case strings.HasPrefix(line, "similarity index "):
+		file.Similarity, _ = strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(line, "similarity index "), "%"))
+	case strings.HasPrefix(line, "index "):
+		// "index abc123..def456 100644" carries the mode when it didn't change
+		fields := strings.Fields(line)
+		if len(fields) == 3 {
+			file.OldMode, file.NewMode = fields[2], fields[2]
+		}
+	case strings.HasPrefix(line, "--- "):
+		if p := filePath(strings.TrimPrefix(line, "--- ")); p != "/dev/null" {
+			file.OldPath = strings.TrimPrefix(p, "a/")
+		}
+	case strings.HasPrefix(line, "+++ "):
+		if p := filePath(strings.TrimPrefix(line, "+++ ")); p != "/dev/null" {
+			file.NewPath = strings.TrimPrefix(p, "b/")
+		}
+	case strings.HasPrefix(line, "Binary files "), line == "GIT binary patch":
+		file.Binary = true
+	}
+}
+
+// filePath decodes the path in a "---" or "+++" line. Git follows unquoted
+// paths containing spaces with a tab, which isn't part of the name.
+func filePath(p string) string {
+	if strings.HasPrefix(p, `"`) {
+		return unquotePath(p)
+	}
+	if i := strings.IndexByte(p, '\t'); i >= 0 {
+		p = p[:i]
+	}
+	return p
+}
+
+// parseGitHeaderPaths extracts the paths from "a/<old> b/<new>". The header
+// is ambiguous when paths contain spaces, so later headers may correct it.
+func parseGitHeaderPaths(s string) (string, string) {
+	if strings.HasPrefix(s, `"`) {
+		// Quoted paths: "a/x y" "b/x y"
+		if end := closingQuote(s); end > 0 {
+			oldPath := unquotePath(s[:end+1])
+			newPath := unquotePath(strings.TrimSpace(s[end+1:]))
+			return strings.TrimPrefix(oldPath, "a/"), strings.TrimPrefix(newPath, "b/")
+		}
+	}
+
+	// Unchanged paths appear twice, so split down the middle
+	if len(s)%2 == 1 {
+		mid := len(s) / 2
+		oldPath, newPath := s[:mid], s[mid+1:]
+		if s[mid] == ' ' && strings.TrimPrefix(oldPath, "a/") == strings.TrimPrefix(newPath, "b/") {
+			return strings.TrimPrefix(oldPath, "a/"), strings.TrimPrefix(newPath, "b/")
+		}
+	}
+
+	if i := strings.LastIndex(s, " b/"); i >= 0 {
+		return strings.TrimPrefix(s[:i], "a/"), s[i+3:]
+	}
+	return s, s
+}
+
+func closingQuote(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+// unquotePath decodes git's C-style quoting of unusual paths
+func unquotePath(p string) string {
+	if len(p) >= 2 && strings.HasPrefix(p, `"`) && strings.HasSuffix(p, `"`) {
+		if unquoted, err := strconv.Unquote(p); err == nil {
+			return unquoted
+		}
+	}
+	return p
+}
+
+// parseHunkHeader parses "@@ -a,b +c,d @@ section"
+func parseHunkHeader(line string) (Hunk, error) {
+	h := Hunk{header: line}
+
+	rest := strings.TrimPrefix(line, "@@ ")
+	end := strings.Index(rest, " @@")
+	if end < 0 {
+		return h, fmt.Errorf("malformed hunk header: %q", line)
+	}
+	h.Section = strings.TrimPrefix(rest[end+3:], " ")
+
+	ranges := strings.Fields(rest[:end])
+	if len(ranges) != 2 || !strings.HasPrefix(ranges[0], "-") || !strings.HasPrefix(ranges[1], "+") {
+		return h, fmt.Errorf("malformed hunk header: %q", line)
+	}
+
+	var err error
+	if h.OldStart, h.OldLines, err = parseRange(ranges[0][1:]); err != nil {
+		return h, fmt.Errorf("malformed hunk header %q: %w", line, err)
+	}
+	if h.NewStart, h.NewLines, err = parseRange(ranges[1][1:]); err != nil {
+		return h, fmt.Errorf("malformed hunk header %q: %w", line, err)
+	}
+	return h, nil
+}
+
+// parseRange parses "start,count" or "start", where count defaults to 1
+func parseRange(s string) (int, int, error) {
+	startStr, countStr, hasCount := strings.Cut(s, ",")
+	start, err := strconv.Atoi(startStr)
+	if err != nil {
+		return 0, 0, err
+	}
+	if !hasCount {
+		return start, 1, nil
+	}
+	count, err := strconv.Atoi(countStr)
+	if err != nil {
+		return 0, 0, err
+	}
+	return start, count, nil
+}
+
+// languages maps file extensions to language names
+var languages = map[string]string{
+	".go":    "Go",
+	".rs":    "Rust",
+	".py":    "Python",
+	".js":    "JavaScript",
+	".mjs":   "JavaScript",
+	".cjs":   "JavaScript",
+	".jsx":   "JavaScript",
+	".ts":    "TypeScript",
+	".tsx":   "TypeScript",
+	".java":  "Java",
+	".kt":    "Kotlin",
+	".swift": "Swift",
+	".c":     "C",
+	".h":     "C",
+	".cc":    "C++",
+	".cpp":   "C++",
+	".hpp":   "C++",
+	".cs":    "C#",
+	".rb":    "Ruby",
+	".php":   "PHP",
+	".scala": "Scala",
+	".sh":    "Shell",
+	".bash":  "Shell",
+	".sql":   "SQL",
+	".html":  "HTML",
+	".css":   "CSS",
+	".scss":  "SCSS",
+	".md":    "Markdown",
+	".json":  "JSON",
+	".yaml":  "YAML",
+	".yml":   "YAML",
+	".toml":  "TOML",
+	".xml":   "XML",
+	".proto": "Protocol Buffers",
+	".tf":    "Terraform",
+	".lua":   "Lua",
+	".dart":  "Dart",
+	".ex":    "Elixir",
+	".exs":   "Elixir",
+	".vue":   "Vue",
+}
+
+// specialFiles maps well-known file names to language names
+var specialFiles = map[string]string{
+	"Dockerfile": "Dockerfile",
+	"Makefile":   "Makefile",
+	"go.mod":     "Go Module",
+	"go.sum":     "Go Module",
+}
+
+// LanguageFor guesses a file's language from its name, returning an empty
+// string when unknown
+func LanguageFor(filePath string) string {
+	base := path.Base(filePath)
+	if lang, ok := specialFiles[base]; ok {
+		return lang
+	}
+	return languages[strings.ToLower(path.Ext(base))]
+}
@@ -0,0 +1,149 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// parseGolden parses testdata/name and checks that formatting the result
+// reproduces the input byte for byte
+func parseGolden(t *testing.T, name string) []*FileDiff {
+	t.Helper()
+
+	raw, err := os.ReadFile(filepath.Join("testdata", name))
+	require.NoError(t, err)
+
+	files, err := ParseDiff(string(raw))
+	require.NoError(t, err)
+	assert.Equal(t, string(raw), FormatDiff(files), "round trip of %s", name)
+	return files
+}
+
+func TestParseDiffModified(t *testing.T) {
+	files := parseGolden(t, "modified.diff")
+	require.Len(t, files, 2)
+
+	main := files[0]
+	assert.Equal(t, "main.go", main.Path())
+	assert.Equal(t, StatusModified, main.Status)
+	assert.Equal(t, "Go", main.Language)
+	assert.Equal(t, 3, main.Added())
+	assert.Equal(t, 1, main.Removed())
+	assert.False(t, main.ModeChanged())
+
+	numbers := files[1]
+	require.Len(t, numbers.Hunks, 2)
+	assert.Equal(t, Hunk{OldStart: 22, OldLines: 7, NewStart: 22, NewLines: 7}, Hunk{
+		OldStart: numbers.Hunks[1].OldStart,
+		OldLines: numbers.Hunks[1].OldLines,
+		NewStart: numbers.Hunks[1].NewStart,
+		NewLines: numbers.Hunks[1].NewLines,
+	})
+	assert.Equal(t, Line{Kind: LineAdded, Text: "twenty-five"}, numbers.Hunks[1].Lines[4])
+}
+
+func TestParseDiffRename(t *testing.T) {
+	files := parseGolden(t, "rename.diff")
+	require.Len(t, files, 1)
+
+	f := files[0]
+	assert.Equal(t, StatusRenamed, f.Status)
+	assert.Equal(t, "notes.txt", f.OldPath)
+	assert.Equal(t, "docs-notes.txt", f.NewPath)
+	assert.Equal(t, 84, f.Similarity)
+	require.Len(t, f.Hunks, 1)
+	assert.Equal(t, "line two", f.Hunks[0].Section)
+	assert.Equal(t, 1, f.Added())
+	assert.Equal(t, 1, f.Removed())
+}
+
+func TestParseDiffDeleted(t *testing.T) {
+	files := parseGolden(t, "deleted.diff")
+	require.Len(t, files, 1)
+
+	f := files[0]
+	assert.Equal(t, StatusDeleted, f.Status)
+	assert.Equal(t, "README.md", f.Path())
+	assert.Equal(t, "Markdown", f.Language)
+	assert.Equal(t, 0, f.Added())
+	assert.Equal(t, 1, f.Removed())
+}
+
+func TestParseDiffBinaryAndMode(t *testing.T) {
+	files := parseGolden(t, "binary_mode.diff")
+	require.Len(t, files, 2)
+
+	logo := files[0]
+	assert.True(t, logo.Binary)
+	assert.Empty(t, logo.Hunks)
+	assert.Equal(t, "logo.png", logo.Path())
+
+	script := files[1]
+	assert.False(t, script.Binary)
+	assert.True(t, script.ModeChanged())
+	assert.Equal(t, "100644", script.OldMode)
+	assert.Equal(t, "100755", script.NewMode)
+	assert.Equal(t, "Shell", script.Language)
+}
+
+func TestParseDiffNoNewlineAtEOF(t *testing.T) {
+	files := parseGolden(t, "no_newline.diff")
+	require.Len(t, files, 1)
+
+	lines := files[0].Hunks[0].Lines
+	require.Len(t, lines, 6)
+	assert.Equal(t, LineNoNewline, lines[2].Kind)
+	assert.Equal(t, LineNoNewline, lines[5].Kind)
+	assert.Equal(t, 2, files[0].Added())
+	assert.Equal(t, 1, files[0].Removed())
+}
+
+func TestParseDiffQuotedPaths(t *testing.T) {
+	files := parseGolden(t, "quoted_paths.diff")
+	require.Len(t, files, 2)
+
+	assert.Equal(t, StatusAdded, files[0].Status)
+	assert.Equal(t, "dir with space/file name.txt", files[0].Path())
+	assert.Equal(t, "we\tird.txt", files[1].Path())
+}
+
+func TestParseDiffErrors(t *testing.T) {
+	_, err := ParseDiff("not a diff\n")
+	assert.ErrorContains(t, err, `expected "diff --git" header`)
+
+	_, err = ParseDiff("diff --git a/x b/x\n@@ -1 +1 @ broken\n")
+	assert.ErrorContains(t, err, "malformed hunk header")
+
+	files, err := ParseDiff("")
+	assert.NoError(t, err)
+	assert.Empty(t, files)
+}
+
+func TestParseDiffUnmergedPath(t *testing.T) {
+	files := parseGolden(t, "unmerged.diff")
+	require.Len(t, files, 2)
+
+	f := files[0]
+	assert.Equal(t, StatusUnmerged, f.Status)
+	assert.Equal(t, "f", f.Path())
+	assert.Empty(t, f.Hunks)
+
+	assert.Equal(t, "g", files[1].Path())
+	assert.Equal(t, StatusAdded, files[1].Status)
+	assert.Equal(t, 1, files[1].Added())
+}
+
+func TestParseDiffCombined(t *testing.T) {
+	files := parseGolden(t, "combined.diff")
+	require.Len(t, files, 1)
+
+	f := files[0]
+	assert.Equal(t, StatusUnmerged, f.Status)
+	assert.Equal(t, "f", f.Path())
+	assert.Empty(t, f.Hunks)
+	assert.Contains(t, f.String(), "++<<<<<<< HEAD")
+}
@@ -0,0 +1,115 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// run executes git with args and returns its trimmed standard output
+func run(args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out.String()), nil
+}
+
+// RepoRoot returns the top-level directory of the current working tree
+func RepoRoot() (string, error) {
+	return run("rev-parse", "--show-toplevel")
+}
+
+// CommentChar returns the character git starts commit message comments
+// with: core.commentChar, or "#" when it isn't set. "auto" means git picks
+// a character the message doesn't use, so it can't be known in advance.
+func CommentChar() string {
+	char, err := run("config", "core.commentChar")
+	if err != nil || char == "" {
+		return "#"
+	}
+	return char
+}
+
+// RecentSubjects returns the subject lines of the last n commits
+func RecentSubjects(n int) ([]string, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+
+	out, err := run("log", "-n", strconv.Itoa(n), "--format=%s")
+	if err != nil {
+		return nil, err
+	}
+	if out == "" {
+		return nil, nil
+	}
+	return strings.Split(out, "\n"), nil
+}
+
+// CommitMessage is a commit's hash and full message
+type CommitMessage struct {
+	Hash    string
+	Message string
+}
+
+// MessagesInRange returns the messages of the commits in a revision range
+// such as "main..HEAD", oldest first
+func MessagesInRange(spec string) ([]CommitMessage, error) {
+	if strings.HasPrefix(spec, "-") {
+		return nil, fmt.Errorf("invalid revision range: %s", spec)
+	}
+	out, err := run("log", "--reverse", "--format="+messageFormat, spec, "--")
+	if err != nil {
+		return nil, fmt.Errorf("invalid revision range %s: %w", spec, err)
+	}
+	return parseMessages(out), nil
+}
+
+// RecentMessages returns the messages of the last n commits that aren't
+// merges, newest first
+func RecentMessages(n int) ([]CommitMessage, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	out, err := run("log", "-n", strconv.Itoa(n), "--no-merges", "--format="+messageFormat)
+	if err != nil {
+		return nil, err
+	}
+	return parseMessages(out), nil
+}
+
+// messageFormat is the git log format read by parseMessages
+const messageFormat = "%H%x00%B%x1e"
+
+func parseMessages(out string) []CommitMessage {
+	var commits []CommitMessage
+	for _, record := range strings.Split(out, "\x1e") {
+		hash, message, ok := strings.Cut(strings.TrimLeft(record, "\n"), "\x00")
+		if ok {
+			commits = append(commits, CommitMessage{Hash: hash, Message: strings.TrimSpace(message)})
+		}
+	}
+	return commits
+}
+
+// Editor returns the editor command git would use, honouring GIT_EDITOR,
+// core.editor, VISUAL and EDITOR in that order
+func Editor() string {
+	if editor, err := run("var", "GIT_EDITOR"); err == nil && editor != "" {
+		return editor
+	}
+
+	// git var fails outside a repository on some versions
+	for _, env := range []string{"GIT_EDITOR", "VISUAL", "EDITOR"} {
+		if editor := os.Getenv(env); editor != "" {
+			return editor
+		}
+	}
+	return "vi"
+}
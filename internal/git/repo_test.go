@@ -0,0 +1,35 @@
+package git
+
+import (
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessagesInRange(t *testing.T) {
+	inTempRepo(t)
+	for _, message := range []string{"chore: init", "feat: add a\n\nWith a body.", "fix: b"} {
+		cmd := exec.Command("git", "-c", "user.name=t", "-c", "user.email=t@example.com", "commit", "-q", "--allow-empty", "-m", message)
+		require.NoError(t, cmd.Run())
+	}
+
+	commits, err := MessagesInRange("HEAD~2..HEAD")
+	require.NoError(t, err)
+	require.Len(t, commits, 2)
+	assert.Equal(t, "feat: add a\n\nWith a body.", commits[0].Message)
+	assert.Equal(t, "fix: b", commits[1].Message)
+	assert.Len(t, commits[0].Hash, 40)
+
+	_, err = MessagesInRange("--all")
+	assert.Error(t, err)
+}
+
+func TestCommentChar(t *testing.T) {
+	inTempRepo(t)
+	assert.Equal(t, "#", CommentChar())
+
+	require.NoError(t, exec.Command("git", "config", "core.commentChar", ";").Run())
+	assert.Equal(t, ";", CommentChar())
+}
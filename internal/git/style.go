@@ -0,0 +1,323 @@
+package git
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Header prefixes recognised by AnalyzeMessages
+const (
+	// PrefixConventional is a Conventional Commits type, e.g. "feat(api): "
+	PrefixConventional = "conventional"
+	// PrefixBracket is a component in brackets, e.g. "[api] "
+	PrefixBracket = "bracket"
+	// PrefixComponent is a component and a colon, e.g. "api: "
+	PrefixComponent = "component"
+	// PrefixNone is a header that starts with its description
+	PrefixNone = "none"
+)
+
+// Tenses of the verb starting a description
+const (
+	TenseImperative = "imperative" // "Add"
+	TensePast       = "past"       // "Added"
+	TensePresent    = "present"    // "Adds"
+)
+
+// CommitStyle describes how a repository's commit messages are written
+type CommitStyle struct {
+	// Sampled is the number of messages analysed
+	Sampled int
+	// Prefix is how headers most commonly start
+	Prefix string
+	// Conventional is the share of headers following Conventional Commits
+	Conventional float64
+	// Ticket is a typical ticket reference, e.g. "PROJ-123", or empty when
+	// headers don't usually carry one
+	Ticket string
+	// TicketFirst is set when the ticket reference leads the header
+	TicketFirst bool
+	// Emoji is set when headers usually start with an emoji
+	Emoji bool
+	// Capitalized is set when descriptions usually start with a capital
+	Capitalized bool
+	// Tense is the usual tense of the verb starting a description
+	Tense string
+	// FullStop is set when headers usually end with a full stop
+	FullStop bool
+	// Length is the median header length
+	Length int
+	// Scopes are the most used scopes or components, most used first
+	Scopes []string
+	// Examples are typical messages, newest first
+	Examples []string
+}
+
+// maxExamples is the number of example messages kept
+const maxExamples = 5
+
+var (
+	conventionalHeader = regexp.MustCompile(`^[a-z]+(?:\(([^()]+)\))?!?: (.+)$`)
+	bracketHeader      = regexp.MustCompile(`^\[([^\]]+)\]:?\s*(.+)$`)
+	componentHeader    = regexp.MustCompile(`^([\w./-]+): (.+)$`)
+	ticketPattern      = regexp.MustCompile(`\b[A-Z][A-Z0-9]+-[0-9]+\b`)
+	shortcodePattern   = regexp.MustCompile(`^:[a-z0-9_+-]+:\s*`)
+	// skippedHeader matches headers that don't show the team's style
+	skippedHeader = regexp.MustCompile(`^(?:(?:fixup|squash|amend)! |Revert "|Merge |WIP\b|wip\b)`)
+)
+
+// AnalyzeStyle samples the last n commit messages and describes their
+// style. Commits whose hashes are in exclude aren't sampled.
+func AnalyzeStyle(n int, exclude map[string]bool) (CommitStyle, error) {
+	commits, err := RecentMessages(n + len(exclude))
+	if err != nil {
+		return CommitStyle{}, err
+	}
+	var messages []string
+	for _, c := range commits {
+		if !exclude[c.Hash] && len(messages) < n {
+			messages = append(messages, c.Message)
+		}
+	}
+	return AnalyzeMessages(messages), nil
+}
+
+// AnalyzeMessages describes the style of messages, given newest first
+func AnalyzeMessages(messages []string) CommitStyle {
+	var style CommitStyle
+	prefixes := map[string]int{}
+	scopes := map[string]int{}
+	var lengths []int
+	var tickets, ticketFirst, emoji, capitalized, fullStop, conventional int
+	tenses := map[string]int{}
+
+	for _, message := range messages {
+		header, _, _ := strings.Cut(strings.TrimSpace(message), "\n")
+		header = strings.TrimSpace(header)
+		if header == "" || skippedHeader.MatchString(header) {
+			continue
+		}
+		style.Sampled++
+		lengths = append(lengths, utf8.RuneCountInString(header))
+
+		rest := header
+		if trimmed := trimEmoji(rest); trimmed != rest {
+			emoji++
+			rest = trimmed
+		}
+
+		if loc := ticketPattern.FindStringIndex(rest); loc != nil {
+			tickets++
+			if style.Ticket == "" {
+				style.Ticket = rest[loc[0]:loc[1]]
+			}
+			if loc[0] <= 1 {
+				ticketFirst++
+				rest = strings.TrimLeft(rest[loc[1]:], "]):- ")
+			}
+		}
+
+		prefix, scope, description := PrefixNone, "", rest
+		if m := conventionalHeader.FindStringSubmatch(rest); m != nil {
+			prefix, scope, description = PrefixConventional, m[1], m[2]
+			conventional++
+		} else if m := bracketHeader.FindStringSubmatch(rest); m != nil {
+			prefix, scope, description = PrefixBracket, m[1], m[2]
+		} else if m := componentHeader.FindStringSubmatch(rest); m != nil {
+			prefix, scope, description = PrefixComponent, m[1], m[2]
+		}
+		prefixes[prefix]++
+		if scope != "" {
+			scopes[scope]++
+		}
+
+		if startsUpper(description) {
+			capitalized++
+		}
+		tenses[tense(description)]++
+		if strings.HasSuffix(description, ".") {
+			fullStop++
+		}
+	}
+
+	if style.Sampled == 0 {
+		return style
+	}
+
+	usually := func(n int) bool { return n*2 > style.Sampled }
+	style.Prefix = mostCommon(prefixes)
+	style.Conventional = float64(conventional) / float64(style.Sampled)
+	if !usually(tickets) {
+		style.Ticket = ""
+	}
+	style.TicketFirst = style.Ticket != "" && ticketFirst*2 > tickets
+	style.Emoji = usually(emoji)
+	style.Capitalized = usually(capitalized)
+	style.Tense = mostCommon(tenses)
+	style.FullStop = usually(fullStop)
+
+	sort.Ints(lengths)
+	style.Length = lengths[len(lengths)/2]
+
+	for _, scope := range byCount(scopes) {
+		if scopes[scope] < 2 || len(style.Scopes) == 8 {
+			break
+		}
+		style.Scopes = append(style.Scopes, scope)
+	}
+
+	style.Examples = examples(messages, style.Length)
+	return style
+}
+
+// IsConventional reports whether most messages follow Conventional Commits
+func (s CommitStyle) IsConventional() bool {
+	return s.Sampled > 0 && s.Conventional >= 0.5
+}
+
+// Guide describes the style as instructions for writing a message
+func (s CommitStyle) Guide() []string {
+	if s.Sampled == 0 {
+		return nil
+	}
+
+	var guide []string
+	switch s.Prefix {
+	case PrefixConventional:
+		guide = append(guide, `Headers start with a Conventional Commits type and optional scope, e.g. "fix(api): ".`)
+	case PrefixBracket:
+		guide = append(guide, `Headers start with the affected component in brackets, e.g. "[api] ".`)
+	case PrefixComponent:
+		guide = append(guide, `Headers start with the affected component and a colon, e.g. "api: ".`)
+	}
+	if s.Emoji {
+		guide = append(guide, "Headers start with an emoji that matches the kind of change.")
+	}
+	if s.Ticket != "" {
+		where := "end"
+		if s.TicketFirst {
+			where = "start"
+		}
+		guide = append(guide, fmt.Sprintf("Headers %s with a ticket reference such as %s.", where, s.Ticket))
+	}
+
+	if s.Capitalized {
+		guide = append(guide, "Descriptions start with a capital letter.")
+	} else {
+		guide = append(guide, "Descriptions start with a lower-case letter.")
+	}
+	switch s.Tense {
+	case TensePast:
+		guide = append(guide, `Descriptions are in the past tense, e.g. "Added", not "Add".`)
+	case TensePresent:
+		guide = append(guide, `Descriptions are in the present tense, e.g. "Adds", not "Add".`)
+	default:
+		guide = append(guide, `Descriptions are in the imperative mood, e.g. "Add", not "Added".`)
+	}
+	if s.FullStop {
+		guide = append(guide, "Headers end with a full stop.")
+	} else {
+		guide = append(guide, "Headers don't end with a full stop.")
+	}
+
+	guide = append(guide, fmt.Sprintf("Headers are typically about %d characters long.", s.Length))
+	if len(s.Scopes) > 0 {
+		guide = append(guide, "Common scopes: "+strings.Join(s.Scopes, ", ")+".")
+	}
+	return guide
+}
+
+// trimEmoji removes an emoji or a gitmoji shortcode such as ":bug:" from
+// the start of header
+func trimEmoji(header string) string {
+	if loc := shortcodePattern.FindStringIndex(header); loc != nil {
+		return header[loc[1]:]
+	}
+	r, size := utf8.DecodeRuneInString(header)
+	if r > 0x2000 && (unicode.Is(unicode.So, r) || r >= 0x1F000) {
+		rest := header[size:]
+		// Variation selectors and joiners follow many emoji
+		rest = strings.TrimLeft(rest, "\ufe0f\u200d")
+		return strings.TrimSpace(rest)
+	}
+	return header
+}
+
+// pastIrregular lists common irregular past tense verbs
+var pastIrregular = map[string]bool{
+	"made": true, "wrote": true, "rewrote": true, "built": true, "rebuilt": true,
+	"ran": true, "got": true, "took": true, "began": true, "broke": true, "kept": true, "left": true,
+}
+
+// tense guesses the tense of the verb starting description
+func tense(description string) string {
+	word, _, _ := strings.Cut(description, " ")
+	word = strings.ToLower(strings.TrimRight(word, ".,:;"))
+	switch {
+	case strings.HasSuffix(word, "ed") || pastIrregular[word]:
+		return TensePast
+	case len(word) > 3 && strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") && !strings.HasSuffix(word, "us"):
+		return TensePresent
+	}
+	return TenseImperative
+}
+
+func startsUpper(s string) bool {
+	for _, r := range s {
+		return unicode.IsUpper(r)
+	}
+	return false
+}
+
+func mostCommon(counts map[string]int) string {
+	if keys := byCount(counts); len(keys) > 0 {
+		return keys[0]
+	}
+	return ""
+}
+
+// byCount returns the keys of counts, most counted first
+func byCount(counts map[string]int) []string {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// typicalLength reports whether header is within half and twice length
+// characters
+func typicalLength(header string, length int) bool {
+	n := utf8.RuneCountInString(header)
+	return n >= length/2 && n <= length*2
+}
+
+// examples picks up to maxExamples recent messages with headers of a
+// typical length, skipping ones that don't show the team's style
+func examples(messages []string, length int) []string {
+	var out []string
+	seen := map[string]bool{}
+	for _, message := range messages {
+		message = strings.TrimSpace(message)
+		header, _, _ := strings.Cut(message, "\n")
+		if skippedHeader.MatchString(header) || seen[header] || !typicalLength(header, length) {
+			continue
+		}
+		seen[header] = true
+		out = append(out, message)
+		if len(out) == maxExamples {
+			break
+		}
+	}
+	return out
+}
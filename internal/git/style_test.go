@@ -0,0 +1,57 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnalyzeMessagesBracketStyle(t *testing.T) {
+	style := AnalyzeMessages([]string{
+		"PROJ-14 [web] Fixed the login redirect",
+		"Merge branch 'main' into feature",
+		"PROJ-13 [api] Added pagination to list endpoints\n\nClients pass a cursor.",
+		"fixup! PROJ-13 [api] Added pagination",
+		"PROJ-12 [api] Removed the legacy token check.",
+		"[cli] Updates help text",
+	})
+
+	assert.Equal(t, 4, style.Sampled)
+	assert.Equal(t, PrefixBracket, style.Prefix)
+	assert.False(t, style.IsConventional())
+	assert.Equal(t, "PROJ-14", style.Ticket)
+	assert.True(t, style.TicketFirst)
+	assert.True(t, style.Capitalized)
+	assert.Equal(t, TensePast, style.Tense)
+	assert.False(t, style.FullStop)
+	assert.Equal(t, []string{"api"}, style.Scopes)
+	assert.Len(t, style.Examples, 4)
+	assert.Contains(t, style.Guide(), `Descriptions are in the past tense, e.g. "Added", not "Add".`)
+}
+
+func TestAnalyzeMessagesConventionalWithGitmoji(t *testing.T) {
+	style := AnalyzeMessages([]string{
+		"✨ feat(api): add pagination",
+		":bug: fix(api): handle empty pages",
+		"🔧 chore: bump deps",
+	})
+
+	assert.True(t, style.IsConventional())
+	assert.True(t, style.Emoji)
+	assert.False(t, style.Capitalized)
+	assert.Equal(t, TenseImperative, style.Tense)
+	assert.Equal(t, []string{"api"}, style.Scopes)
+
+	assert.Nil(t, AnalyzeMessages(nil).Guide())
+}
+
+func TestAnalyzeMessagesCountsCharacters(t *testing.T) {
+	style := AnalyzeMessages([]string{
+		"修复：登录后重定向到首页",
+		"添加：列表接口的分页",
+		"更新：帮助文本",
+	})
+
+	assert.Equal(t, 10, style.Length)
+	assert.Len(t, style.Examples, 3)
+}
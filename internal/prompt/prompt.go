@@ -0,0 +1,373 @@
+package prompt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/AlexThuku/GitCommitAI-/pkg/commit"
+)
+
+// RepoTemplatePath is where a repository can keep a team-wide prompt template,
+// relative to the repository root
+const RepoTemplatePath = ".git-msg/prompt.tmpl"
+
+// Prompt is a rendered prompt ready to be sent to a model
+type Prompt struct {
+	// System holds the instructions, for APIs that accept them separately
+	System string
+	// User holds the request itself, including the diff
+	User string
+	// Diff is the raw diff, for providers with a bespoke request format
+	Diff string
+	// JSON is set when the prompt asks for a JSON object, so providers with
+	// a JSON output mode can enable it
+	JSON bool
+	// Full is set when the prompt asks for a body and footers as well as
+	// the header, so providers can allow a longer answer
+	Full bool
+	// Temperature overrides the provider's sampling temperature when above
+	// zero, so that repeated requests produce different messages
+	Temperature float64
+	// NeedsInstructions is set for summaries and corrections, which only
+	// the instructions tell apart from a request for a commit message
+	NeedsInstructions bool
+}
+
+// Text returns the whole prompt as a single string, for APIs that take one input
+func (p Prompt) Text() string {
+	if p.System == "" {
+		return p.User
+	}
+	return p.System + "\n\n" + p.User
+}
+
+// Type is a commit type the model may choose from
+type Type = commit.Type
+
+// File describes a changed file
+type File struct {
+	Path     string
+	Status   string
+	Language string
+	Added    int
+	Removed  int
+	Binary   bool
+	// Omitted is set when the file's content was left out of the diff
+	Omitted bool
+}
+
+// String describes the file on one line, e.g. "main.go (modified, +3/-1)"
+func (f File) String() string {
+	stats := fmt.Sprintf("+%d/-%d", f.Added, f.Removed)
+	if f.Binary {
+		stats = "binary"
+	}
+	if f.Omitted {
+		stats += ", content not shown"
+	}
+	return fmt.Sprintf("%s (%s, %s)", f.Path, f.Status, stats)
+}
+
+// Data holds the variables available to prompt templates
+type Data struct {
+	Diff          string
+	Files         []File
+	Branch        string
+	RecentCommits []string
+	Types         []Type
+	Scopes        []string
+	Language      string
+	// Summaries replace the diff when it was too large to send at once.
+	// Diff then holds them as text, for templates that only use Diff.
+	Summaries []string
+	// Style is "subject" for a header line only, or "full" to also ask for a
+	// body and footers as JSON
+	Style string
+	// Constraints are further rules the message must follow, such as those
+	// from a commitlint configuration
+	Constraints []string
+	// Convention is the format of the header; nil means Conventional
+	// Commits
+	Convention commit.Convention
+	// Freeform is set when messages should follow the repository's own
+	// style rather than a convention
+	Freeform bool
+	// StyleGuide describes the style of the repository's commit messages
+	StyleGuide []string
+	// Examples are real commit messages from the repository
+	Examples []string
+}
+
+// StyleFull is the Data.Style asking for a body and footers as well as the
+// header line
+const StyleFull = "full"
+
+// DefaultTypes are the Conventional Commits types offered when none are configured
+var DefaultTypes = commit.ConventionalTypes
+
+// knownTypes are the types with a built-in description
+var knownTypes = append(append([]Type{}, commit.ConventionalTypes...), commit.AngularTypes...)
+
+// TypesFromNames resolves configured type names, keeping the built-in
+// description for types that have one
+func TypesFromNames(names []string) []Type {
+	if len(names) == 0 {
+		return DefaultTypes
+	}
+
+	types := make([]Type, 0, len(names))
+	for _, name := range names {
+		t := Type{Name: name}
+		for _, known := range knownTypes {
+			if known.Name == name {
+				t.Description = known.Description
+				break
+			}
+		}
+		types = append(types, t)
+	}
+	return types
+}
+
+// defaultTemplate is used when neither the config nor the repository supply one
+const defaultTemplate = `{{define "system" -}}
+You are a helpful assistant that generates git commit messages based on code diffs.
+{{- if .Freeform}}
+Please analyze the following git diff and generate a clear, concise commit message written in the same style as this repository's existing commit messages.
+{{- else}}
+Please analyze the following git diff and generate a clear, concise commit message following {{.Convention.Title}}.
+
+{{.Convention.Instructions .Types .Scopes}}
+{{- end}}
+
+{{if .Freeform}}The message should be concise but descriptive{{else}}The description should be concise but descriptive, written in imperative mood{{end}}{{if and .Language (ne .Language "English")}}, in {{.Language}}{{end}}.
+{{- range .Constraints}}
+{{.}}
+{{- end}}
+{{- if .StyleGuide}}
+
+Commit messages in this repository follow these conventions:
+{{- range .StyleGuide}}
+- {{.}}
+{{- end}}
+{{- end}}
+{{- if .Examples}}
+
+Recent commit messages from this repository, as examples of the style:
+{{- range .Examples}}
+"""
+{{.}}
+"""
+{{- end}}
+{{- end}}
+{{- if eq .Style "full"}}
+Keep the first line under 72 characters.
+
+Also write a body of one or more short paragraphs explaining what changed and why, and add footers where they apply: "BREAKING CHANGE" describing what breaks for existing users, or "Refs" for referenced issues.
+
+Respond with a single JSON object and no additional text, in this form:
+{{"{"}}{{if .Freeform}}"subject": "<first line>"{{else}}{{.Convention.Schema}}{{end}}, "body": ["<paragraph>"], "footers": [{"token": "<token>", "value": "<value>"}]}
+{{- else}}
+Only output the commit message, no additional text.
+{{- end}}
+{{- end}}
+
+{{define "user" -}}
+{{if .Branch}}Branch: {{.Branch}}
+
+{{end -}}
+{{if .RecentCommits}}Recent commits on this branch:
+{{range .RecentCommits}}- {{.}}
+{{end}}
+{{end -}}
+{{if .Files}}Changed files:
+{{range .Files}}- {{.}}
+{{end}}
+{{end -}}
+{{if .Summaries}}The diff is too large to include. Summaries of the changes to each file:
+{{range .Summaries}}- {{.}}
+{{end}}
+{{- else}}Git diff:
+{{.Diff}}
+{{- end}}
+{{- end}}`
+
+// summaryInstructions ask for a description of one file's changes, used when
+// a diff has to be summarised piece by piece
+const summaryInstructions = `You are a helpful assistant that summarises code changes.
+Describe what the following git diff of a single file changes and why, in one or two short sentences.
+Only output the summary, no additional text.`
+
+// SummaryDiff lists the per-file summaries in place of a diff
+func SummaryDiff(summaries []string) string {
+	var b strings.Builder
+	b.WriteString("The diff is too large to include. Summaries of the changes to each file:\n")
+	for _, s := range summaries {
+		b.WriteString("- " + s + "\n")
+	}
+	return b.String()
+}
+
+// Summary builds the prompt asking for a summary of one file's diff
+func Summary(path, diff string) Prompt {
+	return Prompt{
+		System:            summaryInstructions,
+		User:              "File: " + path + "\n\nGit diff:\n" + diff,
+		Diff:              diff,
+		NeedsInstructions: true,
+	}
+}
+
+// Correction extends p with the model's previous answer and what was wrong
+// with it, asking for a corrected message
+func Correction(p Prompt, previous string, problems []string) Prompt {
+	var b strings.Builder
+	b.WriteString(p.User)
+	b.WriteString("\n\nYour previous commit message was:\n")
+	b.WriteString(previous)
+	b.WriteString("\n\nIt has these problems:\n")
+	for _, problem := range problems {
+		b.WriteString("- " + problem + "\n")
+	}
+	b.WriteString("\nWrite a corrected commit message in the same format.")
+	p.User = b.String()
+	p.NeedsInstructions = true
+	return p
+}
+
+// Template renders prompts from a text/template
+type Template struct {
+	tmpl   *template.Template
+	source string
+}
+
+var funcs = template.FuncMap{
+	"join": strings.Join,
+}
+
+// Default returns the built-in prompt template
+func Default() *Template {
+	t, err := Parse("default", defaultTemplate)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// Parse parses a prompt template. A template that defines "system" and "user"
+// blocks has them rendered separately; otherwise the whole template is the
+// user prompt.
+func Parse(source, text string) (*Template, error) {
+	tmpl, err := template.New(filepath.Base(source)).Funcs(funcs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid prompt template %s: %w", source, err)
+	}
+	return &Template{tmpl: tmpl, source: source}, nil
+}
+
+// Load returns the template to use: the configured path if set, then the
+// repository's .git-msg/prompt.tmpl if present, then the built-in default
+func Load(configPath, repoRoot string) (*Template, error) {
+	if configPath != "" {
+		return loadFile(expandHome(configPath))
+	}
+
+	if repoRoot != "" {
+		path := filepath.Join(repoRoot, RepoTemplatePath)
+		if _, err := os.Stat(path); err == nil {
+			return loadFile(path)
+		}
+	}
+
+	return Default(), nil
+}
+
+func loadFile(path string) (*Template, error) {
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read prompt template: %w", err)
+	}
+	return Parse(path, string(text))
+}
+
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[1:])
+		}
+	}
+	return path
+}
+
+// Source describes where the template was loaded from
+func (t *Template) Source() string {
+	return t.source
+}
+
+// Render fills the template with data
+func (t *Template) Render(data Data) (Prompt, error) {
+	if len(data.Types) == 0 {
+		data.Types = DefaultTypes
+	}
+	if data.Convention == nil {
+		data.Convention = commit.Conventional
+	}
+
+	p, err := t.render(data)
+	if err != nil {
+		return Prompt{}, err
+	}
+
+	if p.User == "" {
+		return Prompt{}, errors.New("prompt template rendered an empty prompt")
+	}
+
+	// OpenAI-style JSON modes require the prompt itself to ask for JSON
+	p.Full = data.Style == StyleFull
+	p.JSON = p.Full && t.asksForJSON(data)
+
+	return p, nil
+}
+
+// render executes the template's blocks with data
+func (t *Template) render(data Data) (Prompt, error) {
+	p := Prompt{Diff: data.Diff}
+
+	user := t.tmpl
+	if block := t.tmpl.Lookup("user"); block != nil {
+		user = block
+		if system := t.tmpl.Lookup("system"); system != nil {
+			var err error
+			if p.System, err = t.execute(system, data); err != nil {
+				return Prompt{}, err
+			}
+		}
+	}
+
+	var err error
+	p.User, err = t.execute(user, data)
+	return p, err
+}
+
+// asksForJSON reports whether the template's instructions ask for JSON. The
+// changes and the repository's history are left out, since they may mention
+// JSON themselves, e.g. in a change to package.json.
+func (t *Template) asksForJSON(data Data) bool {
+	data.Diff, data.Files, data.Summaries = "", nil, nil
+	data.Branch, data.RecentCommits, data.Examples = "", nil, nil
+	p, err := t.render(data)
+	return err == nil && strings.Contains(strings.ToLower(p.Text()), "json")
+}
+
+func (t *Template) execute(tmpl *template.Template, data Data) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render prompt template %s: %w", t.source, err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
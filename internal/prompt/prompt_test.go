@@ -0,0 +1,164 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/AlexThuku/GitCommitAI-/pkg/commit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultTemplate(t *testing.T) {
+	p, err := Default().Render(Data{
+		Diff:          "diff --git a/main.go b/main.go",
+		Files:         []File{{Path: "main.go", Status: "modified", Added: 3, Removed: 1}},
+		Branch:        "feature/login",
+		RecentCommits: []string{"feat: add session store"},
+		Scopes:        []string{"api", "cli"},
+		Language:      "German",
+	})
+	require.NoError(t, err)
+
+	assert.Contains(t, p.System, "- feat: A new feature")
+	assert.Contains(t, p.System, "Where [optional scope] is one of: api, cli")
+	assert.Contains(t, p.System, "written in imperative mood, in German.")
+	assert.Contains(t, p.User, "Branch: feature/login")
+	assert.Contains(t, p.User, "- feat: add session store")
+	assert.Contains(t, p.User, "Changed files:\n- main.go (modified, +3/-1)")
+	assert.Contains(t, p.User, "Git diff:\ndiff --git a/main.go b/main.go")
+	assert.Equal(t, "diff --git a/main.go b/main.go", p.Diff)
+}
+
+func TestDefaultTemplateMinimal(t *testing.T) {
+	p, err := Default().Render(Data{Diff: "some diff", Language: "English"})
+	require.NoError(t, err)
+
+	assert.NotContains(t, p.System, "scope] is one of")
+	assert.Contains(t, p.System, "written in imperative mood.")
+	assert.Equal(t, "Git diff:\nsome diff", p.User)
+}
+
+func TestDefaultTemplateFullStyle(t *testing.T) {
+	p, err := Default().Render(Data{Diff: "some diff", Style: StyleFull})
+	require.NoError(t, err)
+	assert.Contains(t, p.System, "Respond with a single JSON object")
+	assert.True(t, p.JSON)
+	assert.True(t, p.Full)
+
+	p, err = Default().Render(Data{Diff: "some diff"})
+	require.NoError(t, err)
+	assert.NotContains(t, p.System, "JSON")
+	assert.False(t, p.JSON)
+	assert.False(t, p.Full)
+}
+
+func TestCustomFullTemplateWithoutJSON(t *testing.T) {
+	tmpl, err := Parse("custom", "Write a commit message with a body for:\n{{.Diff}}")
+	require.NoError(t, err)
+
+	p, err := tmpl.Render(Data{
+		Diff:  "diff --git a/package.json b/package.json",
+		Files: []File{{Path: "package.json", Status: "modified"}},
+		Style: StyleFull,
+	})
+	require.NoError(t, err)
+	assert.True(t, p.Full)
+	assert.False(t, p.JSON)
+}
+
+func TestDefaultTemplateFreeform(t *testing.T) {
+	p, err := Default().Render(Data{
+		Diff:       "some diff",
+		Freeform:   true,
+		StyleGuide: []string{`Headers start with the affected component in brackets, e.g. "[api] ".`},
+		Examples:   []string{"[api] Added pagination"},
+	})
+	require.NoError(t, err)
+	assert.NotContains(t, p.System, "Conventional Commits")
+	assert.Contains(t, p.System, `- Headers start with the affected component in brackets, e.g. "[api] ".`)
+	assert.Contains(t, p.System, "\"\"\"\n[api] Added pagination\n\"\"\"")
+}
+
+func TestCustomTemplate(t *testing.T) {
+	tmpl, err := Parse("custom", `Summarise {{len .Files}} file(s) for {{.Branch}}:
+{{.Diff}}`)
+	require.NoError(t, err)
+
+	p, err := tmpl.Render(Data{Diff: "d", Files: []File{{Path: "a"}, {Path: "b"}}, Branch: "main"})
+	require.NoError(t, err)
+	assert.Empty(t, p.System)
+	assert.Equal(t, "Summarise 2 file(s) for main:\nd", p.User)
+	assert.Equal(t, p.User, p.Text())
+}
+
+func TestFileString(t *testing.T) {
+	assert.Equal(t, "a.go (modified, +3/-1)", File{Path: "a.go", Status: "modified", Added: 3, Removed: 1}.String())
+	assert.Equal(t, "logo.png (added, binary)", File{Path: "logo.png", Status: "added", Binary: true}.String())
+	assert.Equal(t, "go.sum (modified, +40/-2, content not shown)",
+		File{Path: "go.sum", Status: "modified", Added: 40, Removed: 2, Omitted: true}.String())
+}
+
+func TestCustomTypes(t *testing.T) {
+	types := TypesFromNames([]string{"feat", "deps"})
+	assert.Equal(t, []Type{{Name: "feat", Description: "A new feature"}, {Name: "deps"}}, types)
+
+	p, err := Default().Render(Data{Diff: "d", Types: types})
+	require.NoError(t, err)
+	assert.Contains(t, p.System, "- feat: A new feature\n- deps\n")
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	root := t.TempDir()
+
+	// Without any templates the default is used
+	tmpl, err := Load("", root)
+	require.NoError(t, err)
+	assert.Equal(t, "default", tmpl.Source())
+
+	// A repository template overrides the default
+	repoPath := filepath.Join(root, RepoTemplatePath)
+	require.NoError(t, os.MkdirAll(filepath.Dir(repoPath), 0755))
+	require.NoError(t, os.WriteFile(repoPath, []byte("repo {{.Diff}}"), 0644))
+
+	tmpl, err = Load("", root)
+	require.NoError(t, err)
+	assert.Equal(t, repoPath, tmpl.Source())
+
+	// An explicitly configured template wins
+	configPath := filepath.Join(t.TempDir(), "mine.tmpl")
+	require.NoError(t, os.WriteFile(configPath, []byte("mine {{.Diff}}"), 0644))
+
+	tmpl, err = Load(configPath, root)
+	require.NoError(t, err)
+	p, err := tmpl.Render(Data{Diff: "x"})
+	require.NoError(t, err)
+	assert.Equal(t, "mine x", p.User)
+
+	// A configured template that doesn't exist is an error
+	_, err = Load(filepath.Join(root, "missing.tmpl"), root)
+	assert.Error(t, err)
+}
+
+func TestCorrection(t *testing.T) {
+	p := Correction(Prompt{System: "rules", User: "Git diff:\nx"}, "Update stuff.", []string{"the header must start with a type"})
+	assert.Equal(t, "rules", p.System)
+	assert.Equal(t, "Git diff:\nx\n\nYour previous commit message was:\nUpdate stuff.\n\nIt has these problems:\n- the header must start with a type\n\nWrite a corrected commit message in the same format.", p.User)
+	assert.True(t, p.NeedsInstructions)
+}
+
+func TestDefaultTemplateConvention(t *testing.T) {
+	p, err := Default().Render(Data{Diff: "some diff", Convention: commit.Gitmoji, Scopes: []string{"api"}, Style: StyleFull})
+	require.NoError(t, err)
+	assert.Contains(t, p.System, "following the gitmoji convention.")
+	assert.Contains(t, p.System, "- :sparkles: Introduce new features")
+	assert.Contains(t, p.System, "Where (optional scope) is one of: api")
+	assert.Contains(t, p.System, `{"gitmoji": "<gitmoji>", "scope": "<scope, or empty>", "subject": "<description>", "body"`)
+	assert.NotContains(t, p.System, "<type>")
+}
+
+func TestSummaryDiff(t *testing.T) {
+	assert.Equal(t, "The diff is too large to include. Summaries of the changes to each file:\n- a.go: adds x\n- b.go: removes y\n",
+		SummaryDiff([]string{"a.go: adds x", "b.go: removes y"}))
+}
@@ -0,0 +1,296 @@
+package redact
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Severity ranks how damaging a leaked match would be
+type Severity int
+
+const (
+	Low Severity = iota
+	Medium
+	High
+)
+
+func (s Severity) String() string {
+	switch s {
+	case High:
+		return "high"
+	case Medium:
+		return "medium"
+	default:
+		return "low"
+	}
+}
+
+// ParseSeverity converts "low", "medium" or "high" into a Severity
+func ParseSeverity(s string) (Severity, error) {
+	switch strings.ToLower(s) {
+	case "low":
+		return Low, nil
+	case "medium", "":
+		return Medium, nil
+	case "high":
+		return High, nil
+	}
+	return Low, fmt.Errorf("invalid severity: %s", s)
+}
+
+// Rule detects one kind of sensitive value. If Pattern has a capture group
+// named "secret", only that group is redacted; otherwise the whole match is.
+type Rule struct {
+	Name     string
+	Pattern  *regexp.Regexp
+	Severity Severity
+}
+
+// NewRule compiles a rule from a regular expression
+func NewRule(name, pattern string, severity Severity) (Rule, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return Rule{}, fmt.Errorf("invalid redaction rule %s: %w", name, err)
+	}
+	return Rule{Name: name, Pattern: re, Severity: severity}, nil
+}
+
+func mustRule(name, pattern string, severity Severity) Rule {
+	r, err := NewRule(name, pattern, severity)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// DefaultRules are the built-in detectors
+func DefaultRules() []Rule {
+	return []Rule{
+		mustRule("private_key", `(?s)-----BEGIN [A-Z ]*PRIVATE KEY( BLOCK)?-----.*?-----END [A-Z ]*PRIVATE KEY( BLOCK)?-----`, High),
+		mustRule("aws_access_key_id", `\b(?:AKIA|ASIA|ABIA|ACCA)[0-9A-Z]{16}\b`, High),
+		mustRule("aws_secret_access_key", `(?i)aws[a-z0-9_ .-]{0,20}(?:secret|private)[a-z0-9_ .-]{0,20}['"]?\s*[:=]\s*['"]?(?P<secret>[A-Za-z0-9/+=]{40})\b`, High),
+		mustRule("gcp_api_key", `\bAIza[0-9A-Za-z_\-]{35}\b`, High),
+		mustRule("gcp_service_account_key", `"private_key_id"\s*:\s*"(?P<secret>[a-f0-9]{40})"`, High),
+		mustRule("github_token", `\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`, High),
+		mustRule("gitlab_token", `\bglpat-[A-Za-z0-9_\-]{20,}\b`, High),
+		mustRule("slack_token", `\bxox[abposr]-[A-Za-z0-9-]{10,}\b`, High),
+		mustRule("stripe_key", `\b[rs]k_live_[A-Za-z0-9]{20,}\b`, High),
+		mustRule("api_key", `\bsk-(?:ant-|proj-)?[A-Za-z0-9_\-]{20,}\b`, High),
+		mustRule("connection_string_password", `\b[a-zA-Z][a-zA-Z0-9+.\-]*://[^\s:/@'"]+:(?P<secret>[^\s@/'"]+)@`, High),
+		mustRule("jwt", `\beyJ[A-Za-z0-9_\-]{8,}\.eyJ[A-Za-z0-9_\-]{8,}\.[A-Za-z0-9_\-]{8,}`, Medium),
+		mustRule("password_assignment", `(?i)(?:password|passwd|pwd|secret|token|api[_\-]?key)["']?\s*[:=]\s*["'](?P<secret>[^"'\s]{6,})["']`, Medium),
+		mustRule("email", `\b[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}\b`, Low),
+	}
+}
+
+// Finding is one distinct sensitive value that was redacted
+type Finding struct {
+	Rule        string
+	Severity    Severity
+	Placeholder string
+	// Source names where the value was first seen, e.g. a file path
+	Source string
+	// Count is how many times the value was replaced
+	Count int
+}
+
+// Report lists what a Redactor replaced
+type Report struct {
+	Findings []Finding
+}
+
+// Empty reports whether nothing was redacted
+func (r Report) Empty() bool {
+	return len(r.Findings) == 0
+}
+
+// Highest returns the most severe finding's severity
+func (r Report) Highest() Severity {
+	highest := Low
+	for _, f := range r.Findings {
+		if f.Severity > highest {
+			highest = f.Severity
+		}
+	}
+	return highest
+}
+
+// Lines describes each finding on its own line, most severe first
+func (r Report) Lines() []string {
+	findings := append([]Finding(nil), r.Findings...)
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Severity > findings[j].Severity
+	})
+
+	lines := make([]string, len(findings))
+	for i, f := range findings {
+		lines[i] = fmt.Sprintf("%s %s in %s replaced with %s (%d occurrence(s))",
+			f.Severity, f.Rule, f.Source, f.Placeholder, f.Count)
+	}
+	return lines
+}
+
+// Options tune a Redactor
+type Options struct {
+	// Rules replace DefaultRules when set; use append(DefaultRules(), ...)
+	// to extend them
+	Rules []Rule
+	// Allow lists patterns for values that must never be redacted
+	Allow []*regexp.Regexp
+	// EntropyThreshold enables detection of random-looking strings with at
+	// least this many bits of Shannon entropy per character. Zero disables it.
+	EntropyThreshold float64
+}
+
+// Redactor replaces sensitive values with placeholders. The same value always
+// gets the same placeholder, so the model can still see that two places use
+// the same secret.
+type Redactor struct {
+	rules            []Rule
+	allow            []*regexp.Regexp
+	entropyThreshold float64
+
+	placeholders map[string]int // value -> index into findings
+	counters     map[string]int // rule -> number of distinct values
+	findings     []Finding
+}
+
+// New creates a Redactor
+func New(opts Options) *Redactor {
+	rules := opts.Rules
+	if rules == nil {
+		rules = DefaultRules()
+	}
+	return &Redactor{
+		rules:            rules,
+		allow:            opts.Allow,
+		entropyThreshold: opts.EntropyThreshold,
+		placeholders:     map[string]int{},
+		counters:         map[string]int{},
+	}
+}
+
+// entropyCandidate finds long tokens that may be random secrets
+var entropyCandidate = regexp.MustCompile(`[A-Za-z0-9+/=_\-]{24,}`)
+
+// Redact replaces every sensitive value in text. source identifies the text
+// in the report, e.g. a file path.
+func (r *Redactor) Redact(source, text string) string {
+	for _, rule := range r.rules {
+		text = r.apply(rule, source, text)
+	}
+
+	if r.entropyThreshold > 0 {
+		text = entropyCandidate.ReplaceAllStringFunc(text, func(token string) string {
+			if strings.HasPrefix(token, "REDACTED_") || r.allowed(token) || !looksRandom(token, r.entropyThreshold) {
+				return token
+			}
+			return r.placeholder("high_entropy_string", Medium, source, token)
+		})
+	}
+
+	return text
+}
+
+// Report returns everything redacted so far
+func (r *Redactor) Report() Report {
+	return Report{Findings: append([]Finding(nil), r.findings...)}
+}
+
+func (r *Redactor) apply(rule Rule, source, text string) string {
+	group := rule.Pattern.SubexpIndex("secret")
+
+	matches := rule.Pattern.FindAllStringSubmatchIndex(text, -1)
+	if matches == nil {
+		return text
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		if group > 0 && m[2*group] >= 0 {
+			start, end = m[2*group], m[2*group+1]
+		}
+
+		// Leave allowed values and anything an earlier rule already replaced
+		value := text[start:end]
+		if r.allowed(value) || strings.Contains(value, "[REDACTED_") {
+			continue
+		}
+
+		b.WriteString(text[last:start])
+		b.WriteString(r.placeholder(rule.Name, rule.Severity, source, value))
+		last = end
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+func (r *Redactor) allowed(value string) bool {
+	for _, re := range r.allow {
+		if re.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// placeholder returns the stable placeholder for value, recording a finding
+func (r *Redactor) placeholder(rule string, severity Severity, source, value string) string {
+	if i, ok := r.placeholders[value]; ok {
+		r.findings[i].Count++
+		return r.findings[i].Placeholder
+	}
+
+	r.counters[rule]++
+	f := Finding{
+		Rule:        rule,
+		Severity:    severity,
+		Placeholder: fmt.Sprintf("[REDACTED_%s_%d]", strings.ToUpper(rule), r.counters[rule]),
+		Source:      source,
+		Count:       1,
+	}
+	r.placeholders[value] = len(r.findings)
+	r.findings = append(r.findings, f)
+	return f.Placeholder
+}
+
+// looksRandom reports whether token has high entropy and mixes character
+// classes the way generated secrets do, which rules out most identifiers
+func looksRandom(token string, threshold float64) bool {
+	var lower, upper, digit bool
+	for _, c := range token {
+		switch {
+		case c >= 'a' && c <= 'z':
+			lower = true
+		case c >= 'A' && c <= 'Z':
+			upper = true
+		case c >= '0' && c <= '9':
+			digit = true
+		}
+	}
+	if !digit || !(lower || upper) {
+		return false
+	}
+	return shannonEntropy(token) >= threshold
+}
+
+// shannonEntropy returns the entropy of s in bits per character
+func shannonEntropy(s string) float64 {
+	counts := map[rune]int{}
+	for _, c := range s {
+		counts[c]++
+	}
+
+	var entropy float64
+	n := float64(len(s))
+	for _, count := range counts {
+		p := float64(count) / n
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
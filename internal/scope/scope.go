@@ -0,0 +1,396 @@
+package scope
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/AlexThuku/GitCommitAI-/internal/filter"
+	"gopkg.in/yaml.v3"
+)
+
+// Rule maps paths matching a gitignore-style pattern to a scope
+type Rule struct {
+	Pattern string
+	Scope   string
+}
+
+// Scope is a resolved scope and why it was chosen
+type Scope struct {
+	Name string
+	// Reason describes where the scope came from, e.g. "Go package internal/git"
+	Reason string
+}
+
+// String describes the scope for the user, e.g. "git (Go package internal/git)"
+func (s Scope) String() string {
+	return s.Name + " (" + s.Reason + ")"
+}
+
+// Resolver maps changed paths to commit scopes. For each path it tries, in
+// order: the configured rules, the monorepo workspace containing it, and the
+// Go package containing it. Paths none of these resolve fall back to their
+// common directory.
+type Resolver struct {
+	root       string
+	rules      []rule
+	workspaces []workspace
+	// goModule is set when root holds a Go module
+	goModule bool
+	// hasGo caches whether a directory contains Go files
+	hasGo map[string]bool
+}
+
+type rule struct {
+	matcher *filter.Matcher
+	scope   string
+	pattern string
+}
+
+// workspace is a package of a monorepo
+type workspace struct {
+	dir  string
+	name string
+	kind string
+}
+
+// genericDirs are directory names too general to be a scope
+var genericDirs = map[string]bool{
+	"src": true, "lib": true, "pkg": true, "internal": true, "cmd": true, "app": true,
+}
+
+// New creates a resolver for the repository at root. Workspaces are read from
+// go.work, package.json or pnpm-workspace.yaml, and Cargo.toml.
+func New(root string, rules []Rule) (*Resolver, error) {
+	r := &Resolver{root: root, hasGo: map[string]bool{}}
+	for _, ru := range rules {
+		m, err := filter.Compile([]string{ru.Pattern})
+		if err != nil {
+			return nil, err
+		}
+		if ru.Scope == "" {
+			return nil, fmt.Errorf("scope rule %q has no scope", ru.Pattern)
+		}
+		r.rules = append(r.rules, rule{matcher: m, scope: ru.Scope, pattern: ru.Pattern})
+	}
+
+	if root != "" {
+		r.workspaces = append(r.workspaces, goWorkspaces(root)...)
+		r.workspaces = append(r.workspaces, npmWorkspaces(root)...)
+		r.workspaces = append(r.workspaces, cargoWorkspaces(root)...)
+		// Nested workspaces win over the ones containing them
+		sort.SliceStable(r.workspaces, func(i, j int) bool {
+			return len(r.workspaces[i].dir) > len(r.workspaces[j].dir)
+		})
+
+		_, err := os.Stat(filepath.Join(root, "go.mod"))
+		r.goModule = err == nil
+	}
+	return r, nil
+}
+
+// Resolve returns the scopes of the changed paths, most changed first
+func (r *Resolver) Resolve(paths []string) []Scope {
+	counts := map[string]int{}
+	reasons := map[string]string{}
+	var unresolved []string
+
+	for _, p := range paths {
+		s, ok := r.resolve(p)
+		if !ok {
+			unresolved = append(unresolved, p)
+			continue
+		}
+		counts[s.Name]++
+		if _, seen := reasons[s.Name]; !seen {
+			reasons[s.Name] = s.Reason
+		}
+	}
+
+	if dir := commonDir(unresolved); dir != "" {
+		if name := path.Base(dir); !genericDirs[name] {
+			counts[name] += len(unresolved)
+			if _, seen := reasons[name]; !seen {
+				reasons[name] = "directory " + dir
+			}
+		}
+	}
+
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if counts[names[i]] != counts[names[j]] {
+			return counts[names[i]] > counts[names[j]]
+		}
+		return names[i] < names[j]
+	})
+
+	scopes := make([]Scope, len(names))
+	for i, name := range names {
+		scopes[i] = Scope{Name: name, Reason: reasons[name]}
+	}
+	return scopes
+}
+
+func (r *Resolver) resolve(p string) (Scope, bool) {
+	for _, ru := range r.rules {
+		if ru.matcher.Match(p) {
+			return Scope{Name: ru.scope, Reason: "scope rule " + ru.pattern}, true
+		}
+	}
+	for _, w := range r.workspaces {
+		if within(p, w.dir) {
+			return Scope{Name: w.name, Reason: w.kind + " " + w.dir}, true
+		}
+	}
+	if pkg, ok := r.goPackage(p); ok {
+		return Scope{Name: path.Base(pkg), Reason: "Go package " + pkg}, true
+	}
+	return Scope{}, false
+}
+
+// goPackage returns the directory of the Go package p belongs to: the
+// closest directory containing Go files, below the module root
+func (r *Resolver) goPackage(p string) (string, bool) {
+	if !r.goModule {
+		return "", false
+	}
+	for dir := path.Dir(p); dir != "."; dir = path.Dir(dir) {
+		if r.containsGo(dir) {
+			return dir, true
+		}
+	}
+	return "", false
+}
+
+func (r *Resolver) containsGo(dir string) bool {
+	if has, ok := r.hasGo[dir]; ok {
+		return has
+	}
+	has := false
+	entries, _ := os.ReadDir(filepath.Join(r.root, filepath.FromSlash(dir)))
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".go") {
+			has = true
+			break
+		}
+	}
+	r.hasGo[dir] = has
+	return has
+}
+
+// within reports whether p is inside dir; "." contains every path
+func within(p, dir string) bool {
+	return dir == "." || strings.HasPrefix(p, dir+"/")
+}
+
+// commonDir returns the deepest directory containing all paths
+func commonDir(paths []string) string {
+	if len(paths) == 0 {
+		return ""
+	}
+	common := strings.Split(path.Dir(paths[0]), "/")
+	for _, p := range paths[1:] {
+		parts := strings.Split(path.Dir(p), "/")
+		n := 0
+		for n < len(common) && n < len(parts) && common[n] == parts[n] {
+			n++
+		}
+		common = common[:n]
+	}
+	dir := strings.Join(common, "/")
+	if dir == "." {
+		return ""
+	}
+	return dir
+}
+
+var (
+	goWorkUse      = regexp.MustCompile(`(?ms)^use\s*(?:\(\s*(.*?)\)|(\S+))`)
+	goModulePath   = regexp.MustCompile(`(?m)^module\s+"?([^\s"]+)"?`)
+	majorVersion   = regexp.MustCompile(`^v[0-9]+$`)
+	cargoWorkspace = regexp.MustCompile(`(?ms)^\[workspace\](.*?)(?:^\[|\z)`)
+	cargoMembers   = regexp.MustCompile(`(?s)members\s*=\s*\[(.*?)\]`)
+	cargoName      = regexp.MustCompile(`(?m)^\s*name\s*=\s*"([^"]+)"`)
+	quoted         = regexp.MustCompile(`"([^"]+)"`)
+)
+
+// goWorkspaces reads the modules a go.work file uses
+func goWorkspaces(root string) []workspace {
+	data, err := os.ReadFile(filepath.Join(root, "go.work"))
+	if err != nil {
+		return nil
+	}
+
+	var dirs []string
+	for _, m := range goWorkUse.FindAllStringSubmatch(stripLineComments(string(data)), -1) {
+		if m[2] != "" {
+			dirs = append(dirs, m[2])
+		}
+		dirs = append(dirs, strings.Fields(m[1])...)
+	}
+
+	var out []workspace
+	for _, dir := range dirs {
+		dir = cleanDir(strings.Trim(dir, `"`))
+		if dir == "." {
+			continue
+		}
+		name := path.Base(dir)
+		if mod, err := os.ReadFile(filepath.Join(root, dir, "go.mod")); err == nil {
+			if m := goModulePath.FindSubmatch(mod); m != nil {
+				name = moduleName(string(m[1]))
+			}
+		}
+		out = append(out, workspace{dir: dir, name: name, kind: "Go module"})
+	}
+	return out
+}
+
+// moduleName returns the last element of a module path, skipping a major
+// version suffix such as /v2
+func moduleName(modulePath string) string {
+	parts := strings.Split(modulePath, "/")
+	if n := len(parts); n > 1 && majorVersion.MatchString(parts[n-1]) {
+		return parts[n-2]
+	}
+	return parts[len(parts)-1]
+}
+
+// npmWorkspaces reads the packages of a JavaScript monorepo from the
+// "workspaces" of package.json or from pnpm-workspace.yaml
+func npmWorkspaces(root string) []workspace {
+	var patterns []string
+	if data, err := os.ReadFile(filepath.Join(root, "package.json")); err == nil {
+		var pkg struct {
+			Workspaces json.RawMessage `json:"workspaces"`
+		}
+		if json.Unmarshal(data, &pkg) == nil && len(pkg.Workspaces) > 0 {
+			var yarn struct {
+				Packages []string `json:"packages"`
+			}
+			if json.Unmarshal(pkg.Workspaces, &patterns) != nil && json.Unmarshal(pkg.Workspaces, &yarn) == nil {
+				patterns = yarn.Packages
+			}
+		}
+	}
+	if data, err := os.ReadFile(filepath.Join(root, "pnpm-workspace.yaml")); err == nil {
+		var pnpm struct {
+			Packages []string `yaml:"packages"`
+		}
+		if yaml.Unmarshal(data, &pnpm) == nil {
+			patterns = append(patterns, pnpm.Packages...)
+		}
+	}
+
+	var out []workspace
+	for _, dir := range expandDirs(root, patterns) {
+		name := path.Base(dir)
+		if data, err := os.ReadFile(filepath.Join(root, dir, "package.json")); err == nil {
+			var pkg struct {
+				Name string `json:"name"`
+			}
+			if json.Unmarshal(data, &pkg) == nil && pkg.Name != "" {
+				// "@acme/web" is scoped "web"
+				name = path.Base(pkg.Name)
+			}
+		}
+		out = append(out, workspace{dir: dir, name: name, kind: "workspace"})
+	}
+	return out
+}
+
+// cargoWorkspaces reads the members of a Cargo workspace
+func cargoWorkspaces(root string) []workspace {
+	data, err := os.ReadFile(filepath.Join(root, "Cargo.toml"))
+	if err != nil {
+		return nil
+	}
+	section := cargoWorkspace.FindSubmatch(data)
+	if section == nil {
+		return nil
+	}
+	members := cargoMembers.FindSubmatch(section[1])
+	if members == nil {
+		return nil
+	}
+
+	var patterns []string
+	for _, m := range quoted.FindAllSubmatch(members[1], -1) {
+		patterns = append(patterns, string(m[1]))
+	}
+
+	var out []workspace
+	for _, dir := range expandDirs(root, patterns) {
+		name := path.Base(dir)
+		if manifest, err := os.ReadFile(filepath.Join(root, dir, "Cargo.toml")); err == nil {
+			if m := cargoName.FindSubmatch(manifest); m != nil {
+				name = string(m[1])
+			}
+		}
+		out = append(out, workspace{dir: dir, name: name, kind: "crate"})
+	}
+	return out
+}
+
+// expandDirs expands workspace globs such as "packages/*" to the
+// directories they match, relative to root. Patterns starting with "!"
+// exclude directories.
+func expandDirs(root string, patterns []string) []string {
+	var dirs []string
+	excluded := map[string]bool{}
+	for _, pattern := range patterns {
+		negate := strings.HasPrefix(pattern, "!")
+		pattern = cleanDir(strings.TrimPrefix(pattern, "!"))
+		// "**" isn't supported by filepath.Glob; one level covers most layouts
+		pattern = strings.ReplaceAll(pattern, "**", "*")
+
+		matches, _ := filepath.Glob(filepath.Join(root, filepath.FromSlash(pattern)))
+		for _, m := range matches {
+			if info, err := os.Stat(m); err != nil || !info.IsDir() {
+				continue
+			}
+			rel, err := filepath.Rel(root, m)
+			if err != nil {
+				continue
+			}
+			rel = filepath.ToSlash(rel)
+			if negate {
+				excluded[rel] = true
+			} else {
+				dirs = append(dirs, rel)
+			}
+		}
+	}
+
+	var out []string
+	seen := map[string]bool{}
+	for _, dir := range dirs {
+		if !excluded[dir] && !seen[dir] {
+			seen[dir] = true
+			out = append(out, dir)
+		}
+	}
+	return out
+}
+
+func cleanDir(dir string) string {
+	return path.Clean(strings.TrimSuffix(strings.TrimPrefix(dir, "./"), "/"))
+}
+
+func stripLineComments(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if j := strings.Index(line, "//"); j >= 0 {
+			lines[i] = line[:j]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,85 @@
+package scope
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTree creates files under root from a map of relative paths to content
+func writeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	return root
+}
+
+func names(scopes []Scope) []string {
+	var out []string
+	for _, s := range scopes {
+		out = append(out, s.Name)
+	}
+	return out
+}
+
+func TestResolveGoPackages(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"go.mod":                       "module example.com/tool\n",
+		"internal/git/diff.go":         "package git",
+		"internal/git/testdata/a.diff": "",
+		"cmd/tool/main.go":             "package main",
+		"docs/guide/setup.md":          "",
+	})
+
+	r, err := New(root, nil)
+	require.NoError(t, err)
+
+	scopes := r.Resolve([]string{"internal/git/diff.go", "internal/git/testdata/a.diff", "cmd/tool/main.go"})
+	assert.Equal(t, []string{"git", "tool"}, names(scopes))
+	assert.Equal(t, "git (Go package internal/git)", scopes[0].String())
+
+	// Paths outside any package fall back to their common directory
+	scopes = r.Resolve([]string{"docs/guide/setup.md", "docs/guide/faq.md"})
+	assert.Equal(t, []Scope{{Name: "guide", Reason: "directory docs/guide"}}, scopes)
+
+	assert.Empty(t, r.Resolve([]string{"README.md", "go.mod"}))
+}
+
+func TestResolveRulesBeforeWorkspaces(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"package.json":              `{"private": true, "workspaces": ["packages/*"]}`,
+		"packages/web/package.json": `{"name": "@acme/web"}`,
+		"packages/api/package.json": `{"name": "@acme/api-server"}`,
+		"packages/api/docs/x.md":    "",
+	})
+
+	r, err := New(root, []Rule{{Pattern: "**/docs/", Scope: "docs"}})
+	require.NoError(t, err)
+
+	scopes := r.Resolve([]string{"packages/web/src/App.tsx", "packages/web/src/App.css", "packages/api/docs/x.md", "packages/api/index.js"})
+	assert.Equal(t, []string{"web", "api-server", "docs"}, names(scopes))
+	assert.Equal(t, "workspace packages/web", scopes[0].Reason)
+}
+
+func TestResolveGoWorkAndCargo(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"go.work":                  "go 1.22\n\nuse (\n\t./services/billing // payments\n\t./tools\n)\n",
+		"services/billing/go.mod":  "module example.com/billing/v2\n",
+		"tools/go.mod":             "module example.com/tools\n",
+		"Cargo.toml":               "[workspace]\nmembers = [\n  \"crates/*\",\n]\n\n[profile.release]\nlto = true\n",
+		"crates/parser/Cargo.toml": "[package]\nname = \"acme-parser\"\nversion = \"0.1.0\"\n",
+	})
+
+	r, err := New(root, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"billing"}, names(r.Resolve([]string{"services/billing/invoice.go"})))
+	assert.Equal(t, []string{"acme-parser"}, names(r.Resolve([]string{"crates/parser/src/lib.rs"})))
+}
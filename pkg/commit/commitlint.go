@@ -0,0 +1,666 @@
+package commit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CommitlintFiles are the commitlint configuration files looked for in the
+// repository root, in the order commitlint itself searches them
+var CommitlintFiles = []string{
+	"package.json",
+	".commitlintrc",
+	".commitlintrc.json",
+	".commitlintrc.yaml",
+	".commitlintrc.yml",
+	".commitlintrc.js",
+	".commitlintrc.cjs",
+	".commitlintrc.mjs",
+	"commitlint.config.js",
+	"commitlint.config.cjs",
+	"commitlint.config.mjs",
+}
+
+// Commitlint is the part of a commitlint configuration git-msg understands
+type Commitlint struct {
+	// Path is the file the configuration was read from
+	Path string
+	// Rules are the constraints the configuration sets
+	Rules Rules
+	// Ignored lists presets and rules that were skipped because git-msg
+	// can't check them, and settings whose values aren't declarative
+	Ignored []string
+}
+
+// lintRule is one entry of commitlint's rules object:
+// [level, "always" | "never", value]
+type lintRule struct {
+	Level int
+	Never bool
+	Value interface{}
+}
+
+// conventionalRules are the rules of @commitlint/config-conventional that
+// git-msg can check
+var conventionalRules = map[string]lintRule{
+	"type-enum":              {Level: 2, Value: []interface{}{"build", "chore", "ci", "docs", "feat", "fix", "perf", "refactor", "revert", "style", "test"}},
+	"type-case":              {Level: 2, Value: "lower-case"},
+	"type-empty":             {Level: 2, Never: true},
+	"subject-case":           {Level: 2, Never: true, Value: []interface{}{"sentence-case", "start-case", "pascal-case", "upper-case"}},
+	"subject-empty":          {Level: 2, Never: true},
+	"subject-full-stop":      {Level: 2, Never: true, Value: "."},
+	"header-max-length":      {Level: 2, Value: 100.0},
+	"body-leading-blank":     {Level: 1},
+	"body-max-line-length":   {Level: 2, Value: 100.0},
+	"footer-max-line-length": {Level: 2, Value: 100.0},
+}
+
+// angularRules are the rules of @commitlint/config-angular that git-msg
+// can check
+var angularRules = map[string]lintRule{
+	"type-enum":                {Level: 2, Value: []interface{}{"build", "ci", "docs", "feat", "fix", "perf", "refactor", "revert", "style", "test"}},
+	"type-case":                {Level: 2, Value: "lower-case"},
+	"type-empty":               {Level: 2, Never: true},
+	"scope-case":               {Level: 2, Value: "lower-case"},
+	"subject-case":             {Level: 2, Never: true, Value: []interface{}{"sentence-case", "start-case", "pascal-case", "upper-case"}},
+	"subject-empty":            {Level: 2, Never: true},
+	"subject-full-stop":        {Level: 2, Never: true, Value: "."},
+	"subject-exclamation-mark": {Level: 2, Never: true},
+	"header-max-length":        {Level: 2, Value: 72.0},
+	"body-leading-blank":       {Level: 1},
+	"body-max-line-length":     {Level: 2, Value: 100.0},
+	"footer-max-line-length":   {Level: 2, Value: 100.0},
+}
+
+// presets maps the shareable configurations git-msg knows to their rules
+var presets = map[string]map[string]lintRule{
+	"@commitlint/config-conventional": conventionalRules,
+	"@commitlint/config-angular":      angularRules,
+}
+
+// LoadCommitlint reads the commitlint configuration in dir. It returns nil
+// when there is none.
+func LoadCommitlint(dir string) (*Commitlint, error) {
+	for _, name := range CommitlintFiles {
+		path := filepath.Join(dir, name)
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		raw, skipped, err := decodeCommitlint(name, data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read commitlint configuration %s: %w", path, err)
+		}
+		if raw == nil {
+			continue
+		}
+
+		c, err := parseCommitlint(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid commitlint configuration %s: %w", path, err)
+		}
+		c.Path = path
+		c.Ignored = append(c.Ignored, skipped...)
+		return c, nil
+	}
+	return nil, nil
+}
+
+// decodeCommitlint turns a configuration file into JSON, also returning
+// the settings of a JavaScript configuration that were left out because
+// they aren't declarative. It returns nil for a package.json without a
+// "commitlint" key.
+func decodeCommitlint(name string, data []byte) (json.RawMessage, []string, error) {
+	switch {
+	case name == "package.json":
+		var pkg struct {
+			Commitlint json.RawMessage `json:"commitlint"`
+		}
+		if err := json.Unmarshal(data, &pkg); err != nil {
+			return nil, nil, err
+		}
+		return pkg.Commitlint, nil, nil
+	case strings.HasSuffix(name, ".json"):
+		return data, nil, nil
+	case strings.HasSuffix(name, "js"):
+		return jsToJSON(string(data))
+	default:
+		// .commitlintrc may hold JSON or YAML; YAML covers both
+		var v interface{}
+		if err := yaml.Unmarshal(data, &v); err != nil {
+			return nil, nil, err
+		}
+		raw, err := json.Marshal(v)
+		return raw, nil, err
+	}
+}
+
+func parseCommitlint(raw json.RawMessage) (*Commitlint, error) {
+	var cfg struct {
+		Extends json.RawMessage            `json:"extends"`
+		Rules   map[string]json.RawMessage `json:"rules"`
+	}
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return nil, err
+	}
+
+	var extends []string
+	if len(cfg.Extends) > 0 {
+		var one string
+		if json.Unmarshal(cfg.Extends, &one) == nil {
+			extends = []string{one}
+		} else if err := json.Unmarshal(cfg.Extends, &extends); err != nil {
+			return nil, errors.New("extends must be a string or a list of strings")
+		}
+	}
+
+	c := &Commitlint{}
+	rules := map[string]lintRule{}
+	for _, name := range extends {
+		preset, ok := presets[presetName(name)]
+		if !ok {
+			c.Ignored = append(c.Ignored, name)
+			continue
+		}
+		for rule, r := range preset {
+			rules[rule] = r
+		}
+	}
+
+	for name, value := range cfg.Rules {
+		r, err := parseLintRule(value)
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: %w", name, err)
+		}
+		rules[name] = r
+	}
+
+	names := make([]string, 0, len(rules))
+	for name := range rules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if !c.Rules.apply(name, rules[name]) {
+			c.Ignored = append(c.Ignored, name)
+		}
+	}
+	return c, nil
+}
+
+// presetName expands commitlint's shorthand for shareable configurations,
+// e.g. "@commitlint/config-conventional" may be given as "@commitlint/conventional"
+func presetName(name string) string {
+	if strings.HasPrefix(name, "@commitlint/") && !strings.HasPrefix(name, "@commitlint/config-") {
+		return "@commitlint/config-" + strings.TrimPrefix(name, "@commitlint/")
+	}
+	return name
+}
+
+func parseLintRule(raw json.RawMessage) (lintRule, error) {
+	var parts []interface{}
+	if err := json.Unmarshal(raw, &parts); err != nil || len(parts) == 0 {
+		return lintRule{}, errors.New("expected [level, applicable, value]")
+	}
+
+	level, ok := parts[0].(float64)
+	if !ok || level < 0 || level > 2 {
+		return lintRule{}, fmt.Errorf("invalid level %v", parts[0])
+	}
+	r := lintRule{Level: int(level)}
+
+	if len(parts) > 1 {
+		switch parts[1] {
+		case "always":
+		case "never":
+			r.Never = true
+		default:
+			return lintRule{}, fmt.Errorf("invalid applicable %v (expected \"always\" or \"never\")", parts[1])
+		}
+	}
+	if len(parts) > 2 {
+		r.Value = parts[2]
+	}
+	return r, nil
+}
+
+// apply adds a commitlint rule to r, returning false for rules git-msg can't
+// check. Disabled rules are accepted and undo what a preset configured.
+func (r *Rules) apply(name string, rule lintRule) bool {
+	switch rule.Level {
+	case 0:
+		if r.Disabled == nil {
+			r.Disabled = map[string]bool{}
+		}
+		r.Disabled[name] = true
+	case 1:
+		if r.Warnings == nil {
+			r.Warnings = map[string]bool{}
+		}
+		r.Warnings[name] = true
+	}
+	enabled := rule.Level > 0
+
+	switch name {
+	case "type-enum":
+		r.Types = nil
+		if enabled && !rule.Never {
+			r.Types = stringList(rule.Value)
+		}
+	case "scope-enum":
+		r.Scopes = nil
+		if enabled && !rule.Never {
+			r.Scopes = stringList(rule.Value)
+		}
+	case "scope-empty":
+		r.RequireScope = enabled && rule.Never
+	case "scope-case":
+		r.ScopeCase = CaseRule{}
+		if enabled {
+			r.ScopeCase = CaseRule{Cases: stringList(rule.Value), Never: rule.Never}
+		}
+	case "subject-exclamation-mark":
+		// Requiring the mark on every header can't be met
+		if enabled && !rule.Never {
+			return false
+		}
+		r.NoBreakingMark = enabled
+	case "subject-case":
+		r.SubjectCase = CaseRule{}
+		if enabled {
+			r.SubjectCase = CaseRule{Cases: stringList(rule.Value), Never: rule.Never}
+		}
+	case "header-max-length":
+		r.MaxHeaderLength = 0
+		if n, ok := rule.Value.(float64); ok && enabled && !rule.Never {
+			r.MaxHeaderLength = int(n)
+		}
+	case "type-case", "type-empty", "subject-empty", "subject-full-stop":
+		// Always checked
+	case "body-leading-blank":
+		// Checked when linting; rendered messages always meet it
+	case "footer-leading-blank":
+		// Rendered messages always meet it
+	case "body-max-line-length", "footer-max-line-length":
+		// Both apply to every line after the header
+		if n, ok := rule.Value.(float64); ok && enabled && (r.MaxLineLength == 0 || int(n) < r.MaxLineLength) {
+			r.MaxLineLength = int(n)
+		}
+	default:
+		return !enabled
+	}
+	return true
+}
+
+// stringList accepts a string or a list of strings
+func stringList(v interface{}) []string {
+	switch v := v.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		var out []string
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// Constraints describes the rules for the model, beyond the types and
+// scopes it's already given
+func (r Rules) Constraints() []string {
+	var out []string
+	switch {
+	case r.RequireScope && len(r.Scopes) == 1:
+		out = append(out, fmt.Sprintf("Use the scope %q.", r.Scopes[0]))
+	case r.RequireScope:
+		out = append(out, "A scope is required.")
+	}
+	if len(r.ScopeCase.Cases) > 0 {
+		out = append(out, "The scope "+r.ScopeCase.String()+".")
+	}
+	if len(r.SubjectCase.Cases) > 0 {
+		out = append(out, "The description "+r.SubjectCase.String()+".")
+	}
+	if r.NoBreakingMark {
+		out = append(out, "Don't mark breaking changes with \"!\" in the header; describe them in a BREAKING CHANGE footer.")
+	}
+	if r.Ticket != "" && r.convention().Name() == TicketPrefixed.Name() {
+		out = append(out, fmt.Sprintf("Use the ticket %q.", r.Ticket))
+	}
+	if r.MaxHeaderLength > 0 && r.MaxHeaderLength < SubjectLimit {
+		out = append(out, fmt.Sprintf("The whole header line must be at most %d characters.", r.MaxHeaderLength))
+	}
+	return out
+}
+
+// exportPattern finds the object a JavaScript configuration exports
+var exportPattern = regexp.MustCompile(`(?:module\.exports\s*=|export\s+default)\s*`)
+
+// jsToJSON converts the object literal a commitlint.config.js exports to
+// JSON. Only the declarative part of a configuration is read: object and
+// array literals of strings, numbers and booleans, optionally assigned to a
+// variable first or wrapped in defineConfig(). Comments, single quotes,
+// unquoted keys and trailing commas are fine. Settings holding functions,
+// imports or computed values are left out and returned as skipped, e.g.
+// "ignores" or "scope-enum" for a rule.
+func jsToJSON(src string) (json.RawMessage, []string, error) {
+	src = stripJSComments(src)
+	loc := exportPattern.FindStringIndex(src)
+	if loc == nil {
+		return nil, nil, errors.New("no module.exports or export default found")
+	}
+
+	start, err := jsValueStart(src, loc[1])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cv := &jsConverter{src: src}
+	var b strings.Builder
+	if _, err := cv.convert(start, &b); err != nil {
+		return nil, nil, err
+	}
+	return json.RawMessage(b.String()), cv.skipped, nil
+}
+
+// jsValueStart finds the literal the expression at src[i] stands for,
+// following a variable to its definition and a defineConfig() call to its
+// argument
+func jsValueStart(src string, i int) (int, error) {
+	for followed := 0; followed < 4; followed++ {
+		i = skipSpace(src, i)
+		ident := jsIdentifier(src[i:])
+		if ident == "" {
+			return i, nil
+		}
+
+		if ident == "defineConfig" {
+			if j := skipSpace(src, i+len(ident)); j < len(src) && src[j] == '(' {
+				i = j + 1
+				continue
+			}
+		}
+
+		// export default config; with const config = {...} earlier
+		decl := regexp.MustCompile(`(?:const|let|var)\s+` + regexp.QuoteMeta(ident) + `(?:\s*:\s*[\w.]+)?\s*=\s*`)
+		m := decl.FindStringIndex(src)
+		if m == nil {
+			return i, fmt.Errorf("can't find the definition of %s", ident)
+		}
+		i = m[1]
+	}
+	return i, errors.New("the exported configuration is too indirect to read")
+}
+
+func jsIdentifier(s string) string {
+	i := 0
+	for i < len(s) && (s[i] == '_' || s[i] == '$' || s[i] >= 'a' && s[i] <= 'z' || s[i] >= 'A' && s[i] <= 'Z' || i > 0 && s[i] >= '0' && s[i] <= '9') {
+		i++
+	}
+	return s[:i]
+}
+
+// stripJSComments blanks out // and /* */ comments outside strings
+func stripJSComments(src string) string {
+	out := []byte(src)
+	var quote byte
+	for i := 0; i < len(out); i++ {
+		c := out[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'' || c == '`':
+			quote = c
+		case c == '/' && i+1 < len(out) && out[i+1] == '/':
+			for i < len(out) && out[i] != '\n' {
+				out[i] = ' '
+				i++
+			}
+		case c == '/' && i+1 < len(out) && out[i+1] == '*':
+			end := strings.Index(string(out[i+2:]), "*/")
+			if end < 0 {
+				end = len(out) - i - 2
+			}
+			for j := i; j < i+end+4 && j < len(out); j++ {
+				if out[j] != '\n' {
+					out[j] = ' '
+				}
+			}
+			i += end + 3
+		}
+	}
+	return string(out)
+}
+
+// severities maps commitlint's RuleConfigSeverity enum to rule levels
+var severities = map[string]string{
+	"RuleConfigSeverity.Disabled": "0",
+	"RuleConfigSeverity.Warning":  "1",
+	"RuleConfigSeverity.Error":    "2",
+}
+
+// errNotDeclarative is returned for values only JavaScript can evaluate
+var errNotDeclarative = errors.New("only declarative configurations can be read")
+
+// jsConverter writes a JavaScript value as JSON, leaving out object keys
+// whose values aren't declarative
+type jsConverter struct {
+	src string
+	// path holds the keys leading to the value being converted
+	path []string
+	// skipped lists the keys that were left out
+	skipped []string
+}
+
+// convert writes the JavaScript value starting at src[i] as JSON and
+// returns the index just past it
+func (cv *jsConverter) convert(i int, b *strings.Builder) (int, error) {
+	src := cv.src
+	i = skipSpace(src, i)
+	if i >= len(src) {
+		return i, errors.New("unexpected end of configuration")
+	}
+
+	switch c := src[i]; {
+	case c == '{' || c == '[':
+		closing := byte('}')
+		if c == '[' {
+			closing = ']'
+		}
+		b.WriteByte(c)
+		i++
+		first, written := true, false
+		for {
+			i = skipSpace(src, i)
+			if i >= len(src) {
+				return i, errors.New("unexpected end of configuration")
+			}
+			if src[i] == closing {
+				b.WriteByte(closing)
+				return i + 1, nil
+			}
+			if !first {
+				if src[i] != ',' {
+					return i, fmt.Errorf("unexpected %q", src[i])
+				}
+				// Allow a trailing comma
+				if i = skipSpace(src, i+1); i < len(src) && src[i] == closing {
+					continue
+				}
+			}
+			first = false
+
+			var member strings.Builder
+			if c == '[' {
+				end, err := cv.convert(i, &member)
+				if err != nil {
+					return end, err
+				}
+				i = end
+			} else {
+				key, start, err := convertKey(src, i)
+				if err != nil {
+					return start, err
+				}
+				quoted, _ := json.Marshal(key)
+				member.Write(quoted)
+				member.WriteByte(':')
+
+				cv.path = append(cv.path, key)
+				end, err := cv.convert(start, &member)
+				path := strings.Join(cv.path, ".")
+				cv.path = cv.path[:len(cv.path)-1]
+				if errors.Is(err, errNotDeclarative) {
+					cv.skipped = append(cv.skipped, strings.TrimPrefix(path, "rules."))
+					i = skipJSValue(src, start)
+					continue
+				}
+				if err != nil {
+					return end, err
+				}
+				i = end
+			}
+
+			if written {
+				b.WriteByte(',')
+			}
+			b.WriteString(member.String())
+			written = true
+		}
+	case c == '"' || c == '\'' || c == '`':
+		s, end, err := jsString(src, i)
+		if err != nil {
+			return i, err
+		}
+		quoted, _ := json.Marshal(s)
+		b.Write(quoted)
+		return end, nil
+	default:
+		end := i
+		for end < len(src) && strings.IndexByte("{}[](),:;= \t\r\n", src[end]) < 0 {
+			end++
+		}
+		word := src[i:end]
+		if level, ok := severities[word]; ok {
+			b.WriteString(level)
+			return end, nil
+		}
+		if _, err := strconv.ParseFloat(word, 64); err == nil || word == "true" || word == "false" || word == "null" {
+			b.WriteString(word)
+			return end, nil
+		}
+		if word == "" {
+			word = string(src[i])
+		}
+		return i, fmt.Errorf("unsupported value %q: %w", word, errNotDeclarative)
+	}
+}
+
+// skipJSValue returns the index just past the JavaScript expression
+// starting at src[i]: the next comma or closing bracket outside any
+// brackets or strings
+func skipJSValue(src string, i int) int {
+	depth := 0
+	for ; i < len(src); i++ {
+		switch c := src[i]; c {
+		case '"', '\'', '`':
+			for i++; i < len(src) && src[i] != c; i++ {
+				if src[i] == '\\' {
+					i++
+				}
+			}
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		case ',':
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return i
+}
+
+// convertKey reads an object key and its colon, returning the key and the
+// index of its value
+func convertKey(src string, i int) (string, int, error) {
+	var key string
+	if c := src[i]; c == '"' || c == '\'' {
+		var err error
+		if key, i, err = jsString(src, i); err != nil {
+			return "", i, err
+		}
+	} else {
+		key = jsIdentifier(src[i:])
+		if key == "" {
+			return "", i, fmt.Errorf("unsupported key at %q", src[i])
+		}
+		i += len(key)
+	}
+
+	i = skipSpace(src, i)
+	if i >= len(src) || src[i] != ':' {
+		return "", i, fmt.Errorf("expected ':' after %q", key)
+	}
+	return key, i + 1, nil
+}
+
+// jsString reads the string literal starting at src[i]
+func jsString(src string, i int) (string, int, error) {
+	quote := src[i]
+	var b strings.Builder
+	for j := i + 1; j < len(src); j++ {
+		switch c := src[j]; {
+		case c == quote:
+			return b.String(), j + 1, nil
+		case c == '\\' && j+1 < len(src):
+			j++
+			switch src[j] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			default:
+				b.WriteByte(src[j])
+			}
+		case quote == '`' && c == '$' && j+1 < len(src) && src[j+1] == '{':
+			return "", j, fmt.Errorf("template literals with substitutions: %w", errNotDeclarative)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", len(src), errors.New("unterminated string")
+}
+
+func skipSpace(src string, i int) int {
+	for i < len(src) && strings.IndexByte(" \t\r\n", src[i]) >= 0 {
+		i++
+	}
+	return i
+}
@@ -0,0 +1,173 @@
+package commit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	return dir
+}
+
+func TestLoadCommitlintJS(t *testing.T) {
+	dir := writeConfig(t, "commitlint.config.js", `// Shared with CI
+module.exports = {
+  extends: ['@commitlint/config-conventional'],
+  rules: {
+    /* keep scopes in sync with the monorepo */
+    'scope-enum': [2, 'always', ['api', "web",]],
+    'scope-empty': [RuleConfigSeverity.Error, 'never'],
+    'header-max-length': [1, 'always', 60],
+    'subject-case': [0],
+  },
+};
+`)
+
+	c, err := LoadCommitlint(dir)
+	require.NoError(t, err)
+	require.NotNil(t, c)
+	assert.Equal(t, filepath.Join(dir, "commitlint.config.js"), c.Path)
+	assert.Contains(t, c.Rules.Types, "ci")
+	assert.Equal(t, []string{"api", "web"}, c.Rules.Scopes)
+	assert.True(t, c.Rules.RequireScope)
+	assert.Equal(t, 60, c.Rules.MaxHeaderLength)
+	assert.True(t, c.Rules.Warnings["header-max-length"])
+	assert.Empty(t, c.Rules.SubjectCase.Cases)
+}
+
+func TestLoadCommitlintExportedVariable(t *testing.T) {
+	dir := writeConfig(t, "commitlint.config.mjs", `const Configuration = {
+  rules: { 'type-enum': [2, 'always', ['feat', 'fix']] },
+};
+
+export default Configuration;
+`)
+
+	c, err := LoadCommitlint(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"feat", "fix"}, c.Rules.Types)
+}
+
+func TestLoadCommitlintSkipsCode(t *testing.T) {
+	dir := writeConfig(t, ".commitlintrc.js", `module.exports = {
+  extends: ['@commitlint/config-conventional'],
+  ignores: [(message) => message.startsWith('WIP')],
+  plugins: [require('./commitlint-plugin')],
+  rules: {
+    'scope-enum': async () => [2, 'always', await scopes()],
+    'type-enum': [2, 'always', types()],
+    'header-max-length': [2, 'always', 80],
+  },
+};
+`)
+
+	c, err := LoadCommitlint(dir)
+	require.NoError(t, err)
+	assert.Equal(t, 80, c.Rules.MaxHeaderLength)
+	assert.Empty(t, c.Rules.Scopes)
+	// The preset's types stand in for the ones that can't be read
+	assert.Contains(t, c.Rules.Types, "feat")
+	assert.Subset(t, c.Ignored, []string{"ignores", "plugins", "scope-enum", "type-enum"})
+
+	// Syntax errors still fail
+	dir = writeConfig(t, ".commitlintrc.js", `module.exports = { rules: { 'type-enum': [2, 'always', ['feat'] };`)
+	_, err = LoadCommitlint(dir)
+	assert.Error(t, err)
+}
+
+func TestLoadCommitlintDefineConfig(t *testing.T) {
+	dir := writeConfig(t, "commitlint.config.mjs", `import { defineConfig } from '@commitlint/cli';
+
+export default defineConfig({
+  rules: { 'type-enum': [2, 'always', ['feat', 'fix']] },
+});
+`)
+	c, err := LoadCommitlint(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"feat", "fix"}, c.Rules.Types)
+
+	dir = writeConfig(t, "commitlint.config.js", `const config = defineConfig({ rules: { 'scope-enum': [2, 'always', ['api']] } });
+module.exports = config;
+`)
+	c, err = LoadCommitlint(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"api"}, c.Rules.Scopes)
+}
+
+func TestLoadCommitlintYAMLAndPackageJSON(t *testing.T) {
+	dir := writeConfig(t, ".commitlintrc.yml", `extends:
+  - "@commitlint/config-conventional"
+  - "@acme/commitlint-config"
+rules:
+  subject-case: [2, always, lower-case]
+  signed-off-by: [2, always, "Signed-off-by:"]
+`)
+	c, err := LoadCommitlint(dir)
+	require.NoError(t, err)
+	assert.Equal(t, CaseRule{Cases: []string{"lower-case"}}, c.Rules.SubjectCase)
+	assert.Equal(t, []string{"@acme/commitlint-config", "signed-off-by"}, c.Ignored)
+
+	dir = writeConfig(t, "package.json", `{"name": "web", "commitlint": {"rules": {"type-enum": [2, "always", ["feat"]]}}}`)
+	c, err = LoadCommitlint(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"feat"}, c.Rules.Types)
+
+	dir = writeConfig(t, "package.json", `{"name": "web"}`)
+	c, err = LoadCommitlint(dir)
+	require.NoError(t, err)
+	assert.Nil(t, c)
+}
+
+func TestLoadCommitlintAngularPreset(t *testing.T) {
+	dir := writeConfig(t, ".commitlintrc.json", `{"extends": ["@commitlint/config-angular"]}`)
+	c, err := LoadCommitlint(dir)
+	require.NoError(t, err)
+	assert.Empty(t, c.Ignored)
+	assert.NotContains(t, c.Rules.Types, "chore")
+	assert.Equal(t, 72, c.Rules.MaxHeaderLength)
+
+	rules := c.Rules
+	assert.Empty(t, rules.Lint("fix(api): handle empty pages"))
+
+	var got []string
+	for _, message := range []string{"chore: x", "fix(API): handle empty pages", "feat(api)!: drop v1", "fix: " + strings.Repeat("a", 70)} {
+		for _, v := range rules.Lint(message) {
+			got = append(got, v.Rule)
+		}
+	}
+	assert.Equal(t, []string{"type-enum", "scope-case", "subject-exclamation-mark", "header-max-length"}, got)
+
+	// Only the header is checked for the mark, not a BREAKING CHANGE footer
+	assert.Empty(t, rules.Lint("feat(api): drop v1\n\nBREAKING CHANGE: v1 clients stop working"))
+
+	m, violations := rules.Fix(Message{Type: "feat", Scope: "API", Subject: "drop v1", Breaking: true,
+		Footers: []Footer{{Token: BreakingChange, Value: "v1 clients stop working"}}})
+	assert.Empty(t, violations)
+	assert.Equal(t, "feat(api): drop v1", Conventional.Header(m))
+}
+
+func TestRulesSubjectCase(t *testing.T) {
+	rules := Rules{SubjectCase: CaseRule{Cases: []string{"sentence-case", "start-case", "pascal-case", "upper-case"}, Never: true}}
+
+	m, violations := rules.Fix(Message{Type: "feat", Subject: "Add pagination"})
+	assert.Empty(t, violations)
+	assert.Equal(t, "add pagination", m.Subject)
+
+	// Acronyms can't be lowercased safely, so the model is asked again
+	_, violations = rules.Fix(Message{Type: "feat", Subject: "API pagination"})
+	require.Len(t, violations, 1)
+	assert.Equal(t, "subject-case", violations[0].Rule)
+
+	rules = Rules{RequireScope: true, Warnings: map[string]bool{"scope-empty": true}}
+	violations = rules.Validate(Message{Type: "feat", Subject: "add pagination"})
+	require.Len(t, violations, 1)
+	assert.True(t, violations[0].Warning)
+}
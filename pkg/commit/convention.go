@@ -0,0 +1,106 @@
+package commit
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Type is a kind of change a convention lets messages declare
+type Type struct {
+	Name        string
+	Description string
+}
+
+// Convention is a format for commit message headers, such as Conventional
+// Commits or gitmoji. Bodies and footers are the same in every convention;
+// a convention defines how the header is described to the model, parsed,
+// checked and written.
+type Convention interface {
+	// Name is how the convention is selected in configuration
+	Name() string
+	// Title names the convention in prompts, e.g. "the Conventional Commits
+	// specification"
+	Title() string
+	// Instructions describe the header format to the model, given the types
+	// and scopes it may use
+	Instructions(types []Type, scopes []string) string
+	// Schema lists the header fields of the JSON object the model answers
+	// with in the full style, e.g. `"type": "<type>", "subject": "<description>"`
+	Schema() string
+	// DefaultTypes are offered when no types are configured
+	DefaultTypes() []Type
+	// Scoped reports whether headers carry a scope
+	Scoped() bool
+	// ParseHeader reads a header, returning false when it isn't in the
+	// convention's format
+	ParseHeader(header string) (Message, bool)
+	// Header writes the header of m
+	Header(m Message) string
+	// Validate reports how m's header breaks the convention or r, beyond the
+	// checks of the description and header length all conventions share
+	Validate(r Rules, m Message) []Violation
+	// Fix repairs what it can of m's header without asking the model again
+	Fix(r Rules, m Message) Message
+}
+
+var (
+	conventionsMu sync.RWMutex
+	conventions   = map[string]Convention{}
+)
+
+// Register makes a convention available by name, replacing any registered
+// under the same name
+func Register(c Convention) {
+	conventionsMu.Lock()
+	defer conventionsMu.Unlock()
+	conventions[c.Name()] = c
+}
+
+// Lookup returns the convention registered as name
+func Lookup(name string) (Convention, error) {
+	conventionsMu.RLock()
+	defer conventionsMu.RUnlock()
+	if c, ok := conventions[name]; ok {
+		return c, nil
+	}
+	return nil, fmt.Errorf("unknown commit convention: %s (expected one of %s)", name, strings.Join(conventionNames(), ", "))
+}
+
+// Conventions lists the names of the registered conventions
+func Conventions() []string {
+	conventionsMu.RLock()
+	defer conventionsMu.RUnlock()
+	return conventionNames()
+}
+
+func conventionNames() []string {
+	names := make([]string, 0, len(conventions))
+	for name := range conventions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	Register(Conventional)
+	Register(Angular)
+	Register(Gitmoji)
+	Register(GitmojiUnicode)
+	Register(TicketPrefixed)
+}
+
+// violation builds a violation on the header line
+func violation(rule string, column int, format string, args ...interface{}) Violation {
+	return Violation{Rule: rule, Message: fmt.Sprintf(format, args...), Line: 1, Column: column}
+}
+
+// instructionsWithScopes appends the list of allowed scopes to a
+// convention's instructions
+func instructionsWithScopes(b *strings.Builder, placeholder string, scopes []string) {
+	if len(scopes) > 0 {
+		b.WriteString("\n\nWhere " + placeholder + " is one of: " + strings.Join(scopes, ", "))
+	}
+}
@@ -0,0 +1,122 @@
+package commit
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookup(t *testing.T) {
+	c, err := Lookup("gitmoji")
+	require.NoError(t, err)
+	assert.Equal(t, Gitmoji, c)
+
+	_, err = Lookup("emoji")
+	assert.EqualError(t, err, "unknown commit convention: emoji (expected one of angular, conventional, gitmoji, gitmoji-unicode, ticket)")
+}
+
+func TestGitmoji(t *testing.T) {
+	rules := Rules{Convention: Gitmoji, Scopes: []string{"api"}}
+
+	m, violations, err := rules.Normalize("✨ (api): Add pagination")
+	require.NoError(t, err)
+	assert.Empty(t, violations)
+	assert.Equal(t, ":sparkles: (api): Add pagination", Render(Gitmoji, m, StyleSubject))
+	assert.Equal(t, "✨ (api): Add pagination", Render(GitmojiUnicode, GitmojiUnicode.Fix(rules, m), StyleSubject))
+
+	// A Conventional Commits header is converted
+	m, violations, err = rules.Normalize("fix(api): handle empty pages")
+	require.NoError(t, err)
+	assert.Empty(t, violations)
+	assert.Equal(t, ":bug: (api): handle empty pages", Render(Gitmoji, m, StyleSubject))
+
+	_, violations, err = rules.Normalize(":unicorn: Add magic")
+	require.NoError(t, err)
+	require.Len(t, violations, 1)
+	assert.Equal(t, "gitmoji-enum", violations[0].Rule)
+
+	violations = rules.Lint("Add pagination")
+	require.Len(t, violations, 1)
+	assert.Equal(t, "gitmoji-empty", violations[0].Rule)
+}
+
+func TestTicketPrefixed(t *testing.T) {
+	rules := Rules{Convention: TicketPrefixed}
+
+	m, violations, err := rules.Normalize(`{"ticket": "proj-42", "subject": "Add pagination."}`)
+	require.NoError(t, err)
+	assert.Empty(t, violations)
+	assert.Equal(t, "[PROJ-42] Add pagination", Render(TicketPrefixed, m, StyleSubject))
+
+	m, _, err = rules.Normalize("PROJ-7: Fix login")
+	require.NoError(t, err)
+	assert.Equal(t, Message{Ticket: "PROJ-7", Subject: "Fix login"}, m)
+
+	_, violations, err = rules.Normalize("feat: add pagination")
+	require.NoError(t, err)
+	require.Len(t, violations, 1)
+	assert.Equal(t, "ticket-empty", violations[0].Rule)
+}
+
+func TestAngular(t *testing.T) {
+	rules := Rules{Convention: Angular, Types: []string{"feat", "fix"}}
+
+	m, violations, err := rules.Normalize("feat(api): Add pagination")
+	require.NoError(t, err)
+	assert.Empty(t, violations)
+	assert.Equal(t, "feat(api): add pagination", m.Header())
+
+	assert.Empty(t, rules.Lint("fix: handle HTTP errors"))
+	violations = rules.Lint("fix: Handle errors")
+	require.Len(t, violations, 1)
+	assert.Equal(t, "subject-case", violations[0].Rule)
+	assert.Equal(t, 6, violations[0].Column)
+}
+
+func TestAddTicket(t *testing.T) {
+	assert.Equal(t, "feat: add login\n\nRefs: PROJ-12", AddTicket(Conventional, "feat: add login", "PROJ-12", TicketFooter, "Refs", ""))
+	assert.Equal(t, "feat: add login\n\nBody.\n\nSigned-off-by: A <a@example.com>\nRefs: PROJ-12",
+		AddTicket(Conventional, "feat: add login\n\nBody.\n\nSigned-off-by: A <a@example.com>", "PROJ-12", TicketFooter, "Refs", ""))
+	assert.Equal(t, "feat(PROJ-12): add login\n\nBody.", AddTicket(Conventional, "feat: add login\n\nBody.", "PROJ-12", TicketScope, "Refs", ""))
+	assert.Equal(t, "feat(api): add login\n\nRefs: PROJ-12", AddTicket(Conventional, "feat(api): add login", "PROJ-12", TicketScope, "Refs", ""))
+	assert.Equal(t, ":sparkles: PROJ-12 Add login", AddTicket(Gitmoji, ":sparkles: Add login", "PROJ-12", TicketPrefix, "Refs", ""))
+	assert.Equal(t, "[PROJ-12] Add login", AddTicket(TicketPrefixed, "Add login", "PROJ-12", TicketFooter, "Refs", ""))
+
+	// Messages that mention the ticket are left alone
+	assert.Equal(t, "fix: handle proj-12 timeouts", AddTicket(Conventional, "fix: handle proj-12 timeouts", "PROJ-12", TicketFooter, "Refs", ""))
+	assert.False(t, HasTicket("fix: handle PROJ-123", "PROJ-12", ""))
+	assert.False(t, HasTicket("fix: x\n# PROJ-12", "PROJ-12", ""))
+	assert.False(t, HasTicket("fix: x\n; PROJ-12", "PROJ-12", ";"))
+	assert.True(t, HasTicket("fix: x\n# PROJ-12", "PROJ-12", ";"))
+	assert.Equal(t, "fix: x\n; PROJ-12\n\nRefs: PROJ-12", AddTicket(Conventional, "fix: x\n; PROJ-12", "PROJ-12", TicketFooter, "Refs", ";"))
+}
+
+func TestTicketScopeOutsideScopeEnum(t *testing.T) {
+	rules := Rules{Scopes: []string{"api", "web"}, Ticket: "PROJ-12", TicketPlacement: TicketScope}
+	assert.Equal(t, TicketFooter, rules.EffectiveTicketPlacement())
+
+	message := AddTicket(Conventional, "feat: add login", rules.Ticket, rules.EffectiveTicketPlacement(), "Refs", "")
+	assert.Equal(t, "feat: add login\n\nRefs: PROJ-12", message)
+	assert.Empty(t, rules.Lint(message))
+
+	rules.Scopes = append(rules.Scopes, "PROJ-12")
+	assert.Equal(t, TicketScope, rules.EffectiveTicketPlacement())
+	rules.Scopes = nil
+	assert.Equal(t, TicketScope, rules.EffectiveTicketPlacement())
+}
+
+func TestFixLeavesRoomForTicket(t *testing.T) {
+	subject := "add a login form with remember me and password reset links"
+	for _, placement := range []TicketPlacement{TicketPrefix, TicketScope, TicketFooter} {
+		rules := Rules{MaxHeaderLength: 50, Ticket: "PROJ-1234", TicketPlacement: placement}
+		m, violations := rules.Fix(Message{Type: "feat", Subject: subject})
+		assert.Empty(t, violations)
+
+		message := AddTicket(Conventional, Conventional.Header(m), rules.Ticket, placement, "Refs", "")
+		header, _, _ := strings.Cut(message, "\n")
+		assert.LessOrEqual(t, len(header), 50, "placement %s: %q", placement, header)
+		assert.True(t, HasTicket(message, rules.Ticket, ""))
+	}
+}
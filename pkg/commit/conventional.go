@@ -0,0 +1,200 @@
+package commit
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// ConventionalTypes are the Conventional Commits types offered when none are
+// configured
+var ConventionalTypes = []Type{
+	{"feat", "A new feature"},
+	{"fix", "A bug fix"},
+	{"docs", "Documentation changes"},
+	{"style", "Changes that don't affect code functionality (formatting, etc.)"},
+	{"refactor", "Code changes that neither fix bugs nor add features"},
+	{"perf", "Performance improvements"},
+	{"test", "Adding or correcting tests"},
+	{"chore", "Changes to build process, dependencies, etc."},
+}
+
+// AngularTypes are the types of the Angular commit message guidelines
+var AngularTypes = []Type{
+	{"build", "Changes that affect the build system or external dependencies"},
+	{"ci", "Changes to the CI configuration files and scripts"},
+	{"docs", "Documentation only changes"},
+	{"feat", "A new feature"},
+	{"fix", "A bug fix"},
+	{"perf", "A code change that improves performance"},
+	{"refactor", "A code change that neither fixes a bug nor adds a feature"},
+	{"test", "Adding missing tests or correcting existing tests"},
+}
+
+// Conventional is the Conventional Commits specification, e.g.
+// "feat(api)!: add pagination"
+var Conventional Convention = conventional{
+	name:  "conventional",
+	title: "the Conventional Commits specification",
+	types: ConventionalTypes,
+}
+
+// Angular is the Angular commit message format: Conventional Commits headers
+// with Angular's types and descriptions that don't start with a capital
+var Angular Convention = conventional{
+	name:         "angular",
+	title:        "the Angular commit message guidelines",
+	types:        AngularTypes,
+	lowerSubject: true,
+}
+
+type conventional struct {
+	name  string
+	title string
+	types []Type
+	// lowerSubject requires descriptions to start in lower case unless the
+	// rules set their own subject case
+	lowerSubject bool
+}
+
+func (c conventional) Name() string         { return c.name }
+func (c conventional) Title() string        { return c.title }
+func (c conventional) DefaultTypes() []Type { return c.types }
+func (c conventional) Scoped() bool         { return true }
+
+func (c conventional) Instructions(types []Type, scopes []string) string {
+	var b strings.Builder
+	b.WriteString("The format should be: <type>[optional scope]: <description>\n\nWhere <type> is one of:")
+	for _, t := range types {
+		b.WriteString("\n- " + t.Name)
+		if t.Description != "" {
+			b.WriteString(": " + t.Description)
+		}
+	}
+	instructionsWithScopes(&b, "[optional scope]", scopes)
+	if c.lowerSubject {
+		b.WriteString("\n\nDon't capitalize the first letter of the description.")
+	}
+	return b.String()
+}
+
+func (c conventional) Schema() string {
+	return `"type": "<type>", "scope": "<scope, or empty>", "breaking": false, "subject": "<description>"`
+}
+
+func (c conventional) ParseHeader(header string) (Message, bool) {
+	h := headerPattern.FindStringSubmatch(header)
+	if h == nil {
+		return Message{}, false
+	}
+	return Message{Type: h[1], Scope: h[2], Breaking: h[3] != "", Subject: h[4]}, true
+}
+
+// Header writes e.g. "feat(api)!: add pagination"
+func (c conventional) Header(m Message) string {
+	if m.Type == "" {
+		return m.Subject
+	}
+
+	var b strings.Builder
+	b.WriteString(m.Type)
+	if m.Scope != "" {
+		b.WriteString("(" + m.Scope + ")")
+	}
+	if m.Breaking {
+		b.WriteString("!")
+	}
+	b.WriteString(": ")
+	b.WriteString(m.Subject)
+	return b.String()
+}
+
+func (c conventional) Validate(r Rules, m Message) []Violation {
+	var violations []Violation
+	switch {
+	case m.Type == "":
+		violations = append(violations, violation("type-empty", 1, "the header must start with a type, e.g. \"feat: \""))
+	case m.Type != strings.ToLower(m.Type):
+		violations = append(violations, violation("type-case", 1, "type %q must be lower case", m.Type))
+	case len(r.Types) > 0 && !contains(r.Types, m.Type):
+		violations = append(violations, violation("type-enum", 1, "type %q is not one of %s", m.Type, strings.Join(r.Types, ", ")))
+	}
+
+	violations = append(violations, r.validateScope(m.Scope, utf8.RuneCountInString(m.Type)+1)...)
+
+	if subject := strings.TrimSpace(m.Subject); c.lowerSubject && len(r.SubjectCase.Cases) == 0 && startsUpper(subject) && !acronym(subject) {
+		column := utf8.RuneCountInString(c.Header(m)) - utf8.RuneCountInString(m.Subject) + 1
+		violations = append(violations, violation("subject-case", column, "the description must not start with a capital letter"))
+	}
+	return violations
+}
+
+// typeAliases maps types models commonly invent to Conventional Commits types
+var typeAliases = map[string]string{
+	"feature":       "feat",
+	"features":      "feat",
+	"add":           "feat",
+	"bug":           "fix",
+	"bugfix":        "fix",
+	"hotfix":        "fix",
+	"fixes":         "fix",
+	"doc":           "docs",
+	"documentation": "docs",
+	"tests":         "test",
+	"testing":       "test",
+	"refactoring":   "refactor",
+	"performance":   "perf",
+	"chores":        "chore",
+	"deps":          "build",
+	"dependencies":  "build",
+}
+
+func (c conventional) Fix(r Rules, m Message) Message {
+	m.Type = strings.ToLower(strings.TrimSpace(m.Type))
+	if alias, ok := typeAliases[m.Type]; ok && !contains(r.Types, m.Type) && (len(r.Types) == 0 || contains(r.Types, alias)) {
+		m.Type = alias
+	}
+	m.Scope = r.fixScope(m.Scope)
+
+	if c.lowerSubject && len(r.SubjectCase.Cases) == 0 && !acronym(m.Subject) {
+		m.Subject = lowerFirst(m.Subject)
+	}
+	return m
+}
+
+// validateScope checks a scope against the allowed scopes. column is where
+// the scope, or the place it belongs, starts.
+func (r Rules) validateScope(scope string, column int) []Violation {
+	var violations []Violation
+	if scope == "" && r.RequireScope {
+		violations = append(violations, violation("scope-empty", column, "a scope is required"))
+	}
+	if scope != "" && len(r.Scopes) > 0 && !contains(r.Scopes, scope) {
+		violations = append(violations, violation("scope-enum", column+1, "scope %q is not one of %s", scope, strings.Join(r.Scopes, ", ")))
+	}
+	if scope != "" && !r.ScopeCase.Allows(scope) {
+		violations = append(violations, violation("scope-case", column+1, "the scope %s", r.ScopeCase))
+	}
+	return violations
+}
+
+// fixScope uses the configured spelling of a scope, or drops one that isn't
+// allowed. A missing scope is filled in when exactly one is required.
+func (r Rules) fixScope(scope string) string {
+	scope = strings.TrimSpace(scope)
+	if scope != "" && len(r.Scopes) > 0 && !contains(r.Scopes, scope) {
+		canonical := ""
+		for _, s := range r.Scopes {
+			if strings.EqualFold(s, scope) {
+				canonical = s
+			}
+		}
+		scope = canonical
+	}
+	if scope == "" && r.RequireScope && len(r.Scopes) == 1 {
+		scope = r.Scopes[0]
+	}
+	if lower := strings.ToLower(scope); !r.ScopeCase.Allows(scope) && r.ScopeCase.Allows(lower) {
+		scope = lower
+	}
+	return scope
+}
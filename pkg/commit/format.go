@@ -0,0 +1,336 @@
+package commit
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Violation describes one way a message breaks the rules. Rule names follow
+// commitlint's, e.g. "type-enum".
+type Violation struct {
+	Rule    string
+	Message string
+	// Warning is set for rules that shouldn't fail validation
+	Warning bool
+	// Line and Column locate the problem in the message, counting from 1
+	Line   int
+	Column int
+}
+
+func (v Violation) String() string {
+	return v.Rule + ": " + v.Message
+}
+
+// Rules are the constraints a message must meet
+type Rules struct {
+	// Convention is the format of the header; nil means Conventional
+	// Commits
+	Convention Convention
+	// Types lists the allowed types; empty allows any
+	Types []string
+	// Scopes lists the allowed scopes; empty allows any
+	Scopes []string
+	// MaxHeaderLength limits the header line; zero means SubjectLimit
+	MaxHeaderLength int
+	// MaxLineLength limits body and footer lines when linting; zero allows
+	// any length
+	MaxLineLength int
+	// RequireScope rejects messages without a scope
+	RequireScope bool
+	// ScopeCase restricts the case of the scope
+	ScopeCase CaseRule
+	// SubjectCase restricts the case of the description
+	SubjectCase CaseRule
+	// NoBreakingMark rejects headers that mark a breaking change with "!",
+	// e.g. "feat!: drop v1"
+	NoBreakingMark bool
+	// Ticket is the issue key the change belongs to, e.g. from the branch
+	// name, filled in where the convention requires one
+	Ticket string
+	// TicketPlacement is where AddTicket will put Ticket, so Fix leaves room
+	// for it in the header
+	TicketPlacement TicketPlacement
+	// Warnings lists rules whose violations are only warnings
+	Warnings map[string]bool
+	// Disabled lists rules that aren't checked
+	Disabled map[string]bool
+	// CommentChar starts the comment lines Lint ignores, as set by git's
+	// core.commentChar; empty means DefaultCommentChar
+	CommentChar string
+	// Freeform is set when messages follow the repository's own style
+	// rather than the convention, whose header checks are then skipped
+	Freeform bool
+}
+
+// CaseRule requires text to be in one of Cases, or with Never, in none of
+// them. Case names follow commitlint: lower-case, upper-case, camel-case,
+// kebab-case, pascal-case, sentence-case, snake-case and start-case.
+type CaseRule struct {
+	Cases []string
+	Never bool
+}
+
+// Allows reports whether text meets the rule
+func (c CaseRule) Allows(text string) bool {
+	if len(c.Cases) == 0 || text == "" {
+		return true
+	}
+	for _, name := range c.Cases {
+		if IsCase(text, name) {
+			return !c.Never
+		}
+	}
+	return c.Never
+}
+
+// String describes the rule for people and prompts
+func (c CaseRule) String() string {
+	if c.Never {
+		return "must not be " + strings.Join(c.Cases, ", ")
+	}
+	return "must be " + strings.Join(c.Cases, " or ")
+}
+
+var casePatterns = map[string]*regexp.Regexp{
+	"camel-case":  regexp.MustCompile(`^[a-z][a-zA-Z0-9]*$`),
+	"kebab-case":  regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`),
+	"pascal-case": regexp.MustCompile(`^[A-Z][a-zA-Z0-9]*$`),
+	"snake-case":  regexp.MustCompile(`^[a-z0-9]+(_[a-z0-9]+)*$`),
+}
+
+// IsCase reports whether text is in the named case
+func IsCase(text, name string) bool {
+	switch name {
+	case "lower-case", "lowercase":
+		return text == strings.ToLower(text)
+	case "upper-case", "uppercase":
+		return text == strings.ToUpper(text)
+	case "sentence-case", "sentencecase":
+		return startsUpper(text)
+	case "start-case", "startcase":
+		for _, word := range strings.Fields(text) {
+			if !startsUpper(word) {
+				return false
+			}
+		}
+		return true
+	}
+	if re, ok := casePatterns[name]; ok {
+		return re.MatchString(text)
+	}
+	return false
+}
+
+func startsUpper(s string) bool {
+	for _, r := range s {
+		return unicode.IsUpper(r)
+	}
+	return false
+}
+
+func (r Rules) maxHeaderLength() int {
+	if r.MaxHeaderLength > 0 {
+		return r.MaxHeaderLength
+	}
+	return SubjectLimit
+}
+
+// convention returns the convention the rules check, Conventional Commits
+// unless another is set
+func (r Rules) convention() Convention {
+	if r.Convention != nil {
+		return r.Convention
+	}
+	return Conventional
+}
+
+// Validate reports every rule m breaks
+func (r Rules) Validate(m Message) []Violation {
+	var violations []Violation
+	add := func(v Violation) {
+		if r.Disabled[v.Rule] {
+			return
+		}
+		v.Warning = r.Warnings[v.Rule]
+		violations = append(violations, v)
+	}
+
+	c := r.convention()
+	if !r.Freeform {
+		for _, v := range c.Validate(r, m) {
+			add(v)
+		}
+	}
+
+	header := c.Header(m)
+	subject := strings.TrimSpace(m.Subject)
+	column := utf8.RuneCountInString(header) - utf8.RuneCountInString(m.Subject) + 1
+	switch {
+	case subject == "":
+		add(violation("subject-empty", column, "the description must not be empty"))
+	case strings.HasSuffix(subject, "."):
+		add(violation("subject-full-stop", utf8.RuneCountInString(strings.TrimRight(header, " ")), "the description must not end with a full stop"))
+	case !r.SubjectCase.Allows(subject):
+		add(violation("subject-case", column, "the description %s", r.SubjectCase))
+	}
+
+	if r.NoBreakingMark {
+		if i := strings.Index(header, "!:"); i >= 0 {
+			add(violation("subject-exclamation-mark", utf8.RuneCountInString(header[:i])+1, "breaking changes must not be marked with \"!\" in the header"))
+		}
+	}
+
+	if n := utf8.RuneCountInString(header); n > r.maxHeaderLength() {
+		add(violation("header-max-length", r.maxHeaderLength()+1, "the header is %d characters long; the limit is %d", n, r.maxHeaderLength()))
+	}
+
+	return violations
+}
+
+// Fix repairs what can be repaired without asking the model again, and
+// returns the fixed message with the violations that remain
+func (r Rules) Fix(m Message) (Message, []Violation) {
+	c := r.convention()
+	m = c.Fix(r, m)
+
+	// The "!" isn't needed when a BREAKING CHANGE footer says the same
+	if _, ok := m.Footer(BreakingChange); ok && r.NoBreakingMark {
+		m.Breaking = false
+	}
+
+	m.Subject = cleanSubject(m.Subject)
+	if !r.SubjectCase.Allows(m.Subject) {
+		m.Subject = r.fixCase(m.Subject)
+	}
+
+	if excess := r.headerLength(c, m) - r.maxHeaderLength(); excess > 0 {
+		m.Subject = shorten(m.Subject, utf8.RuneCountInString(m.Subject)-excess)
+	}
+
+	return m, r.Validate(m)
+}
+
+// headerLength is the length of m's header in characters once AddTicket
+// has added the ticket to it
+func (r Rules) headerLength(c Convention, m Message) int {
+	header := c.Header(m)
+	if r.Ticket != "" {
+		header, _, _ = strings.Cut(AddTicket(c, header, r.Ticket, r.EffectiveTicketPlacement(), "", r.CommentChar), "\n")
+	}
+	return utf8.RuneCountInString(header)
+}
+
+// fixCase changes the case of the first letter when that satisfies the
+// subject case rule, leaving acronyms such as "API" alone
+func (r Rules) fixCase(subject string) string {
+	if acronym(subject) {
+		return subject
+	}
+
+	first, size := utf8.DecodeRuneInString(subject)
+	for _, candidate := range []string{
+		string(unicode.ToLower(first)) + subject[size:],
+		string(unicode.ToUpper(first)) + subject[size:],
+	} {
+		if r.SubjectCase.Allows(candidate) {
+			return candidate
+		}
+	}
+	return subject
+}
+
+// acronym reports whether s starts with an acronym such as "API"
+func acronym(s string) bool {
+	_, size := utf8.DecodeRuneInString(s)
+	next, _ := utf8.DecodeRuneInString(s[size:])
+	return unicode.IsUpper(next)
+}
+
+// lowerFirst lower-cases the first letter of s
+func lowerFirst(s string) string {
+	first, size := utf8.DecodeRuneInString(s)
+	if size == 0 {
+		return s
+	}
+	return string(unicode.ToLower(first)) + s[size:]
+}
+
+// Normalize cleans model output, parses it and fixes what it can
+func (r Rules) Normalize(text string) (Message, []Violation, error) {
+	m, err := ParseWith(r.convention(), Clean(text))
+	if err != nil {
+		return Message{}, nil, err
+	}
+	m, violations := r.Fix(m)
+	return m, violations, nil
+}
+
+// cleanSubject tidies the description: no wrapping quotes or emphasis,
+// single spaces, and no trailing full stop
+func cleanSubject(subject string) string {
+	subject = strings.Join(strings.Fields(subject), " ")
+	subject = trimWrapping(subject)
+	subject = strings.TrimRight(subject, ". ")
+	return subject
+}
+
+// preamble matches chatty lead-ins such as "Here is your commit message:"
+var preamble = regexp.MustCompile(`(?i)^(?:(?:sure|certainly|okay|ok)[!,.]?\s*)?(?:here(?:'s| is)[^:\n]*|(?:suggested |proposed |generated )?commit message)\s*:\s*`)
+
+// markdownHeading matches the marker of a markdown heading, but not an
+// issue number such as "#123"
+var markdownHeading = regexp.MustCompile(`^\s*#+\s+`)
+
+// Clean strips the artefacts models commonly wrap commit messages in:
+// markdown code fences, lead-ins like "Here is your commit message:",
+// surrounding quotes, and markdown emphasis or headings on the header
+func Clean(text string) string {
+	text = strings.TrimSpace(stripFences(text))
+
+	// A lead-in may sit on its own line or before the message on the same line
+	for {
+		loc := preamble.FindStringIndex(text)
+		if loc == nil {
+			break
+		}
+		text = strings.TrimSpace(stripFences(strings.TrimSpace(text[loc[1]:])))
+	}
+
+	text = trimWrapping(text)
+
+	lines := strings.SplitN(text, "\n", 2)
+	header := strings.TrimSpace(markdownHeading.ReplaceAllString(lines[0], ""))
+	lines[0] = trimWrapping(header)
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// trimWrapping removes quotes, backticks or markdown emphasis wrapped around s
+func trimWrapping(s string) string {
+	for {
+		trimmed := false
+		for _, pair := range []string{`"`, "'", "`", "**", "__", "“"} {
+			closing := pair
+			if pair == "“" {
+				closing = "”"
+			}
+			if len(s) >= len(pair)+len(closing) && strings.HasPrefix(s, pair) && strings.HasSuffix(s, closing) {
+				s = strings.TrimSpace(s[len(pair) : len(s)-len(closing)])
+				trimmed = true
+			}
+		}
+		if !trimmed {
+			return s
+		}
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package commit
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClean(t *testing.T) {
+	tests := map[string]string{
+		"Here is your commit message:\n\n```\nfeat: add login\n```": "feat: add login",
+		"Sure! Here's a commit message: \"fix: handle EOF\"":        "fix: handle EOF",
+		"Commit message: `docs: update README`":                     "docs: update README",
+		"**feat(api): add pagination**\n\nBody stays.":              "feat(api): add pagination\n\nBody stays.",
+		"## chore: bump deps":                                       "chore: bump deps",
+		"'refactor: split parser'":                                  "refactor: split parser",
+		"feat: already clean":                                       "feat: already clean",
+		"#123 Fix login":                                            "#123 Fix login",
+		"### #123 Fix login":                                        "#123 Fix login",
+	}
+	for input, want := range tests {
+		assert.Equal(t, want, Clean(input), input)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	rules := Rules{Types: []string{"feat", "fix"}, Scopes: []string{"api"}}
+
+	assert.Empty(t, rules.Validate(Message{Type: "feat", Scope: "api", Subject: "add pagination"}))
+
+	violations := rules.Validate(Message{Type: "docs", Scope: "web", Subject: "update readme."})
+	var names []string
+	for _, v := range violations {
+		names = append(names, v.Rule)
+	}
+	assert.Equal(t, []string{"type-enum", "scope-enum", "subject-full-stop"}, names)
+
+	violations = rules.Validate(Message{Subject: "Update readme"})
+	require.Len(t, violations, 1)
+	assert.Equal(t, "type-empty", violations[0].Rule)
+}
+
+func TestValidateCountsCharacters(t *testing.T) {
+	// 46 characters, but 126 bytes
+	m := Message{Type: "feat", Subject: strings.Repeat("添加分页", 10)}
+	assert.Empty(t, Rules{MaxHeaderLength: 50}.Validate(m))
+
+	rules := Rules{MaxHeaderLength: 30}
+	violations := rules.Validate(m)
+	require.Len(t, violations, 1)
+	assert.Equal(t, Violation{Rule: "header-max-length", Column: 31, Message: "the header is 46 characters long; the limit is 30", Line: 1}, violations[0])
+
+	m, violations = rules.Fix(m)
+	assert.Empty(t, violations)
+	assert.True(t, utf8.ValidString(m.Subject))
+	assert.Equal(t, 30, utf8.RuneCountInString(m.Header()))
+
+	violations = rules.Validate(Message{Type: "feat", Subject: "添加分页."})
+	require.Len(t, violations, 1)
+	assert.Equal(t, 11, violations[0].Column)
+}
+
+func TestFix(t *testing.T) {
+	rules := Rules{Types: []string{"feat", "fix", "docs"}, Scopes: []string{"API"}}
+
+	m, violations := rules.Fix(Message{Type: "Feature", Scope: "api", Subject: " \"add  pagination.\" "})
+	assert.Empty(t, violations)
+	assert.Equal(t, Message{Type: "feat", Scope: "API", Subject: "add pagination"}, m)
+
+	m, violations = rules.Fix(Message{Type: "bugfix", Scope: "web", Subject: "handle EOF"})
+	assert.Empty(t, violations)
+	assert.Equal(t, "fix: handle EOF", m.Header())
+
+	// Types that can't be mapped are left for the model to correct
+	_, violations = rules.Fix(Message{Type: "chore", Subject: "bump deps"})
+	require.Len(t, violations, 1)
+	assert.Equal(t, "type-enum", violations[0].Rule)
+
+	// A single required scope replaces a missing or disallowed one
+	rules = Rules{Scopes: []string{"git"}, RequireScope: true}
+	m, violations = rules.Fix(Message{Type: "fix", Scope: "diff", Subject: "handle renames"})
+	assert.Empty(t, violations)
+	assert.Equal(t, "fix(git): handle renames", m.Header())
+}
+
+func TestNormalize(t *testing.T) {
+	m, violations, err := Rules{}.Normalize("Here is the commit message:\n```\nFix: Handle EOF on stdin.\n```")
+	require.NoError(t, err)
+	assert.Empty(t, violations)
+	assert.Equal(t, "fix: Handle EOF on stdin", m.Header())
+}
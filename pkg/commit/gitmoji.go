@@ -0,0 +1,227 @@
+package commit
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// GitmojiEntry is an emoji of the gitmoji guide and the change it marks
+type GitmojiEntry struct {
+	Code        string
+	Emoji       string
+	Description string
+	// Type is the Conventional Commits type the emoji stands for, if any
+	Type string
+}
+
+// Gitmojis are the emoji of the gitmoji guide, https://gitmoji.dev
+var Gitmojis = []GitmojiEntry{
+	{":sparkles:", "✨", "Introduce new features", "feat"},
+	{":bug:", "🐛", "Fix a bug", "fix"},
+	{":ambulance:", "🚑️", "Critical hotfix", ""},
+	{":adhesive_bandage:", "🩹", "Simple fix for a non-critical issue", ""},
+	{":memo:", "📝", "Add or update documentation", "docs"},
+	{":bulb:", "💡", "Add or update comments in source code", ""},
+	{":art:", "🎨", "Improve structure / format of the code", "style"},
+	{":recycle:", "♻️", "Refactor code", "refactor"},
+	{":zap:", "⚡️", "Improve performance", "perf"},
+	{":white_check_mark:", "✅", "Add, update, or pass tests", "test"},
+	{":test_tube:", "🧪", "Add a failing test", ""},
+	{":wrench:", "🔧", "Add or update configuration files", "chore"},
+	{":package:", "📦️", "Add or update compiled files or packages", "build"},
+	{":construction_worker:", "👷", "Add or update CI build system", "ci"},
+	{":arrow_up:", "⬆️", "Upgrade dependencies", ""},
+	{":arrow_down:", "⬇️", "Downgrade dependencies", ""},
+	{":heavy_plus_sign:", "➕", "Add a dependency", ""},
+	{":heavy_minus_sign:", "➖", "Remove a dependency", ""},
+	{":fire:", "🔥", "Remove code or files", ""},
+	{":truck:", "🚚", "Move or rename resources", ""},
+	{":boom:", "💥", "Introduce breaking changes", ""},
+	{":lock:", "🔒️", "Fix security or privacy issues", ""},
+	{":rotating_light:", "🚨", "Fix compiler / linter warnings", ""},
+	{":pencil2:", "✏️", "Fix typos", ""},
+	{":rewind:", "⏪️", "Revert changes", "revert"},
+	{":lipstick:", "💄", "Add or update the UI and style files", ""},
+	{":globe_with_meridians:", "🌐", "Internationalization and localization", ""},
+	{":label:", "🏷️", "Add or update types", ""},
+	{":card_file_box:", "🗃️", "Perform database related changes", ""},
+	{":loud_sound:", "🔊", "Add or update logs", ""},
+	{":mute:", "🔇", "Remove logs", ""},
+	{":wheelchair:", "♿️", "Improve accessibility", ""},
+	{":see_no_evil:", "🙈", "Add or update a .gitignore file", ""},
+	{":bookmark:", "🔖", "Release / Version tags", ""},
+	{":rocket:", "🚀", "Deploy stuff", ""},
+	{":tada:", "🎉", "Begin a project", ""},
+	{":construction:", "🚧", "Work in progress", ""},
+	{":technologist:", "🧑‍💻", "Improve developer experience", ""},
+}
+
+// Gitmoji is the gitmoji convention written with shortcodes, e.g.
+// ":sparkles: (api): Add pagination"
+var Gitmoji Convention = gitmoji{name: "gitmoji"}
+
+// GitmojiUnicode is the gitmoji convention written with the emoji
+// themselves, e.g. "✨ (api): Add pagination"
+var GitmojiUnicode Convention = gitmoji{name: "gitmoji-unicode", unicode: true}
+
+type gitmoji struct {
+	name    string
+	unicode bool
+}
+
+// shortcode matches a leading gitmoji shortcode such as ":bug:"
+var shortcode = regexp.MustCompile(`^:[a-z0-9_+-]+:`)
+
+// gitmojiScope matches the scope that may follow the gitmoji
+var gitmojiScope = regexp.MustCompile(`^\(([^()]*)\):?\s*`)
+
+func (g gitmoji) Name() string         { return g.name }
+func (g gitmoji) Title() string        { return "the gitmoji convention" }
+func (g gitmoji) DefaultTypes() []Type { return nil }
+func (g gitmoji) Scoped() bool         { return true }
+
+func (g gitmoji) Instructions(types []Type, scopes []string) string {
+	var b strings.Builder
+	b.WriteString("The format should be: <gitmoji> [(optional scope):] <description>\n\nWhere <gitmoji> is the one that best describes the change, from:")
+	for _, e := range Gitmojis {
+		b.WriteString("\n- " + g.form(e) + " " + e.Description)
+	}
+	instructionsWithScopes(&b, "(optional scope)", scopes)
+	return b.String()
+}
+
+func (g gitmoji) Schema() string {
+	return `"gitmoji": "<gitmoji>", "scope": "<scope, or empty>", "subject": "<description>"`
+}
+
+func (g gitmoji) ParseHeader(header string) (Message, bool) {
+	emoji, rest := leadingEmoji(header)
+	if emoji == "" {
+		return Message{}, false
+	}
+	m := Message{Gitmoji: emoji}
+	if s := gitmojiScope.FindStringSubmatch(rest); s != nil {
+		m.Scope = s[1]
+		rest = rest[len(s[0]):]
+	}
+	m.Subject = strings.TrimSpace(rest)
+	return m, true
+}
+
+// Header writes e.g. ":sparkles: (api): Add pagination"
+func (g gitmoji) Header(m Message) string {
+	var b strings.Builder
+	if m.Gitmoji != "" {
+		b.WriteString(m.Gitmoji + " ")
+	}
+	if m.Scope != "" {
+		b.WriteString("(" + m.Scope + "): ")
+	}
+	b.WriteString(m.Subject)
+	return b.String()
+}
+
+func (g gitmoji) Validate(r Rules, m Message) []Violation {
+	var violations []Violation
+	switch _, known := lookupGitmoji(m.Gitmoji); {
+	case m.Gitmoji == "":
+		violations = append(violations, violation("gitmoji-empty", 1, "the header must start with a gitmoji, e.g. %q", g.form(Gitmojis[0])))
+	case !known:
+		violations = append(violations, violation("gitmoji-enum", 1, "%q is not a gitmoji", m.Gitmoji))
+	}
+	return append(violations, r.validateScope(m.Scope, utf8.RuneCountInString(g.Header(Message{Gitmoji: m.Gitmoji}))+1)...)
+}
+
+// Fix writes known gitmoji in the convention's form, and picks one from the
+// type when the model wrote a Conventional Commits type instead
+func (g gitmoji) Fix(r Rules, m Message) Message {
+	m.Gitmoji = strings.TrimSpace(m.Gitmoji)
+	if m.Gitmoji == "" && m.Type == "" {
+		// Models used to Conventional Commits often write its header instead
+		if h, ok := Conventional.ParseHeader(m.Subject); ok && g.fromType(h) != "" {
+			if h.Scope == "" {
+				h.Scope = m.Scope
+			}
+			h.Breaking = h.Breaking || m.Breaking
+			h.Body, h.Footers = m.Body, m.Footers
+			m = h
+		}
+	}
+	if m.Gitmoji == "" {
+		m.Gitmoji = g.fromType(m)
+	}
+	if e, ok := lookupGitmoji(m.Gitmoji); ok {
+		m.Gitmoji = g.form(e)
+	}
+	m.Scope = r.fixScope(m.Scope)
+	return m
+}
+
+// fromType returns the gitmoji for m's type, or "" when there is none
+func (g gitmoji) fromType(m Message) string {
+	if m.Breaking {
+		e, _ := lookupGitmoji(":boom:")
+		return g.form(e)
+	}
+	name := strings.ToLower(strings.TrimSpace(m.Type))
+	if alias, ok := typeAliases[name]; ok {
+		name = alias
+	}
+	for _, e := range Gitmojis {
+		if name != "" && e.Type == name {
+			return g.form(e)
+		}
+	}
+	return ""
+}
+
+func (g gitmoji) form(e GitmojiEntry) string {
+	if g.unicode {
+		return e.Emoji
+	}
+	return e.Code
+}
+
+// lookupGitmoji finds a gitmoji by shortcode or emoji; emoji match with or
+// without a variation selector
+func lookupGitmoji(s string) (GitmojiEntry, bool) {
+	if s == "" {
+		return GitmojiEntry{}, false
+	}
+	bare := strings.TrimSuffix(s, "\ufe0f")
+	for _, e := range Gitmojis {
+		if e.Code == s || strings.TrimSuffix(e.Emoji, "\ufe0f") == bare {
+			return e, true
+		}
+	}
+	return GitmojiEntry{}, false
+}
+
+// leadingEmoji splits a header into the shortcode or emoji it starts with
+// and the rest
+func leadingEmoji(header string) (emoji, rest string) {
+	if loc := shortcode.FindStringIndex(header); loc != nil {
+		return header[:loc[1]], strings.TrimSpace(header[loc[1]:])
+	}
+
+	end := 0
+	for end < len(header) {
+		r, size := utf8.DecodeRuneInString(header[end:])
+		// Variation selectors and joiners continue an emoji
+		continues := end > 0 && (r == '\ufe0f' || r == '\u200d')
+		if !continues && !isEmoji(r) {
+			break
+		}
+		end += size
+	}
+	if end == 0 {
+		return "", header
+	}
+	return header[:end], strings.TrimSpace(header[end:])
+}
+
+func isEmoji(r rune) bool {
+	return r > 0x2000 && (unicode.Is(unicode.So, r) || r >= 0x1F000)
+}
@@ -0,0 +1,157 @@
+package commit
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// scissors, after the comment character, marks the start of text git
+// removes from a message, such as the diff shown by git commit --verbose
+const scissors = " ------------------------ >8 ------------------------"
+
+// DefaultCommentChar starts comment lines unless core.commentChar says
+// otherwise
+const DefaultCommentChar = "#"
+
+// exemptPattern matches headers git and hosting services write themselves,
+// which commitlint doesn't check either
+var exemptPattern = regexp.MustCompile(`^(?:Merge (?:branch|pull request|remote-tracking branch|tag|[0-9a-f]{7,40})|Merged? .+ into .+|Revert ".*"|(?:fixup|squash|amend)! |Automatic merge|Auto-merged .+ into .+|Initial commit$)`)
+
+// Exempt reports whether a header is one git or a hosting service wrote,
+// such as "Merge branch 'main'" or "fixup! feat: ...", and so isn't checked
+func Exempt(header string) bool {
+	return exemptPattern.MatchString(header)
+}
+
+// Strip removes what git would remove from a message before committing it:
+// lines starting with commentChar, everything below a scissors line, and
+// surrounding blank lines. Runs of blank lines are collapsed into one. An
+// empty commentChar means DefaultCommentChar.
+func Strip(text, commentChar string) string {
+	lines, _ := stripLines(text, commentChar, true)
+	return strings.Join(lines, "\n")
+}
+
+// stripLines returns the lines Strip keeps, with the number each had in
+// text, counting from 1. Without comments, lines starting with
+// commentChar and scissors lines are kept as ordinary text.
+func stripLines(text, commentChar string, comments bool) ([]string, []int) {
+	if commentChar == "" {
+		commentChar = DefaultCommentChar
+	}
+
+	var (
+		lines   []string
+		numbers []int
+	)
+	for i, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		if comments && line == commentChar+scissors {
+			break
+		}
+		if comments && strings.HasPrefix(line, commentChar) {
+			continue
+		}
+		line = strings.TrimRight(line, " \t")
+		if line == "" && len(lines) > 0 && lines[len(lines)-1] == "" {
+			continue
+		}
+		lines = append(lines, line)
+		numbers = append(numbers, i+1)
+	}
+
+	// Drop surrounding blank lines
+	for len(lines) > 0 && lines[0] == "" {
+		lines, numbers = lines[1:], numbers[1:]
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines, numbers = lines[:len(lines)-1], numbers[:len(numbers)-1]
+	}
+	return lines, numbers
+}
+
+// Lint checks a message as it was written, by hand or by git-msg, and
+// reports where each problem is. Unlike Validate it checks the layout of
+// the body as well as the header, and it ignores comments and headers that
+// are Exempt. Positions refer to the lines of text as given, comments
+// included.
+func (r Rules) Lint(text string) []Violation {
+	return r.lint(text, true)
+}
+
+// LintCommitted checks the message of an existing commit. Git removed its
+// comments when the commit was made, so lines starting with the comment
+// character, such as a "#123 fix login" header, are part of the message.
+func (r Rules) LintCommitted(text string) []Violation {
+	return r.lint(text, false)
+}
+
+// lint implements Lint and LintCommitted, removing comment lines first if
+// comments is set
+func (r Rules) lint(text string, comments bool) []Violation {
+	lines, numbers := stripLines(text, r.CommentChar, comments)
+	text = strings.Join(lines, "\n")
+	if strings.TrimSpace(text) == "" {
+		return []Violation{{Rule: "subject-empty", Message: "the message is empty", Line: 1, Column: 1}}
+	}
+
+	header := lines[0]
+	if Exempt(header) {
+		return nil
+	}
+
+	var violations []Violation
+	add := func(rule string, line, column int, format string, args ...interface{}) {
+		if !r.Disabled[rule] {
+			violations = append(violations, Violation{
+				Rule:    rule,
+				Message: fmt.Sprintf(format, args...),
+				Warning: r.Warnings[rule],
+				Line:    line,
+				Column:  column,
+			})
+		}
+	}
+
+	// The parsed header may differ from the line as written, e.g. in
+	// spacing or in a "!" added for a BREAKING CHANGE footer, so it's
+	// checked here rather than by Validate
+	for _, v := range r.Validate(parseText(r.convention(), text)) {
+		if v.Rule != "header-max-length" && v.Rule != "subject-exclamation-mark" {
+			violations = append(violations, v)
+		}
+	}
+	if n, limit := utf8.RuneCountInString(header), r.maxHeaderLength(); n > limit {
+		add("header-max-length", 1, limit+1, "the header is %d characters long; the limit is %d", n, limit)
+	}
+	if i := strings.Index(header, "!:"); r.NoBreakingMark && i >= 0 {
+		add("subject-exclamation-mark", 1, utf8.RuneCountInString(header[:i])+1, "breaking changes must not be marked with \"!\" in the header")
+	}
+
+	if len(lines) > 1 && lines[1] != "" {
+		add("body-leading-blank", 2, 1, "the body must be separated from the header by a blank line")
+	}
+	if limit := r.MaxLineLength; limit > 0 {
+		for i, line := range lines[1:] {
+			// Long URLs and other unbreakable words can't be wrapped
+			if n := utf8.RuneCountInString(line); n > limit && strings.Contains(strings.TrimSpace(line), " ") {
+				add("body-max-line-length", i+2, limit+1, "line is %d characters long; the limit is %d", n, limit)
+			}
+		}
+	}
+
+	for i, v := range violations {
+		if v.Line >= 1 && v.Line <= len(numbers) {
+			violations[i].Line = numbers[v.Line-1]
+		}
+	}
+	sort.SliceStable(violations, func(i, j int) bool {
+		if violations[i].Line != violations[j].Line {
+			return violations[i].Line < violations[j].Line
+		}
+		return violations[i].Column < violations[j].Column
+	})
+	return violations
+}
@@ -0,0 +1,112 @@
+package commit
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLint(t *testing.T) {
+	rules := Rules{Types: []string{"feat", "fix"}, Scopes: []string{"api"}, MaxLineLength: 40}
+
+	violations := rules.Lint(`feat(web): Add pagination.
+The list endpoints now return a cursor for the next page of results.
+
+# Please enter the commit message for your changes.
+# ------------------------ >8 ------------------------
+diff --git a/a.go b/a.go
+`)
+	var got []string
+	for _, v := range violations {
+		got = append(got, v.Rule)
+	}
+	assert.Equal(t, []string{"scope-enum", "subject-full-stop", "body-leading-blank", "body-max-line-length"}, got)
+	assert.Equal(t, 1, violations[0].Line)
+	assert.Equal(t, 6, violations[0].Column)
+	assert.Equal(t, 26, violations[1].Column)
+	assert.Equal(t, 2, violations[3].Line)
+}
+
+func TestLintExemptAndEmpty(t *testing.T) {
+	rules := Rules{Types: []string{"feat"}}
+	assert.Empty(t, rules.Lint("Merge branch 'main' into feature"))
+	assert.Empty(t, rules.Lint("fixup! feat: add pagination"))
+	assert.Empty(t, rules.Lint("Revert \"feat: add pagination\"\n\nThis reverts commit abc."))
+
+	violations := rules.Lint("# only comments\n\n")
+	require.Len(t, violations, 1)
+	assert.Equal(t, "subject-empty", violations[0].Rule)
+
+	rules.Disabled = map[string]bool{"type-enum": true}
+	assert.Empty(t, rules.Lint("chore: tidy"))
+}
+
+func TestLintPositionsAndCommentChar(t *testing.T) {
+	rules := Rules{Types: []string{"feat"}, MaxLineLength: 20, CommentChar: ";"}
+
+	violations := rules.Lint(`; Please enter the commit message for your changes.
+
+feat: add pagination
+; written by hand
+body
+with a line that is far too long to read
+# not a comment
+; ------------------------ >8 ------------------------
+diff --git a/a.go b/a.go
+`)
+	var got []string
+	for _, v := range violations {
+		got = append(got, fmt.Sprintf("%s@%d", v.Rule, v.Line))
+	}
+	// Lines refer to the file as written, comments included
+	assert.Equal(t, []string{"body-leading-blank@5", "body-max-line-length@6"}, got)
+
+	assert.Equal(t, "feat: add pagination\nbody\nwith a line that is far too long to read\n# not a comment",
+		Strip("; note\n\nfeat: add pagination\n; written by hand\nbody\nwith a line that is far too long to read\n# not a comment\n", ";"))
+}
+
+func TestLintFreeform(t *testing.T) {
+	rules := Rules{Types: []string{"feat"}, Scopes: []string{"api"}, Freeform: true}
+
+	// Type and scope aren't checked, but the description still is
+	assert.Empty(t, rules.Lint("Add pagination to the list endpoints"))
+	assert.Empty(t, rules.Lint("chore(web): tidy"))
+
+	violations := rules.Lint("Add pagination.")
+	require.Len(t, violations, 1)
+	assert.Equal(t, "subject-full-stop", violations[0].Rule)
+
+	rules.Freeform = false
+	assert.NotEmpty(t, rules.Lint("Add pagination to the list endpoints"))
+}
+
+func TestLintCommitted(t *testing.T) {
+	rules := Rules{Types: []string{"fix"}}
+
+	// Lint treats the header as a comment and lints the body instead
+	assert.Empty(t, rules.Lint("#123 fix login\n\nfix: handle expired sessions"))
+
+	violations := rules.LintCommitted("#123 fix login\n\nfix: handle expired sessions")
+	require.NotEmpty(t, violations)
+	assert.Equal(t, 1, violations[0].Line)
+}
+
+func TestStrip(t *testing.T) {
+	message := "\n\nfeat: add editor  \n\n\n\n- first\n# a comment\n- second\n\nSigned-off-by: A <a@example.com>\n\n# trailing\n"
+	assert.Equal(t, "feat: add editor\n\n- first\n- second\n\nSigned-off-by: A <a@example.com>", Strip(message, ""))
+	assert.Equal(t, "", Strip("# only comments\n\n#\n", ""))
+}
+
+func TestLintCountsCharacters(t *testing.T) {
+	rules := Rules{MaxHeaderLength: 20, MaxLineLength: 20}
+
+	// Each of these is within its limit in characters, but not in bytes
+	assert.Empty(t, rules.Lint("feat: 添加分页和排序\n\n为列表 添加分页和排序功能"))
+
+	violations := rules.Lint("feat: 为用户列表添加分页和排序功能以及过滤\n\n为用户列表 添加分页和排序功能以及过滤功能")
+	require.Len(t, violations, 2)
+	assert.Equal(t, Violation{Rule: "header-max-length", Message: "the header is 24 characters long; the limit is 20", Line: 1, Column: 21}, violations[0])
+	assert.Equal(t, Violation{Rule: "body-max-line-length", Message: "line is 21 characters long; the limit is 20", Line: 3, Column: 21}, violations[1])
+}
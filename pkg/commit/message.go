@@ -0,0 +1,219 @@
+package commit
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// SubjectLimit is the maximum length of a rendered header line
+const SubjectLimit = 72
+
+// BodyWidth is the column the body is wrapped at
+const BodyWidth = 72
+
+// Style selects how much of a message is generated and rendered
+type Style string
+
+const (
+	// StyleSubject is a single header line
+	StyleSubject Style = "subject"
+	// StyleFull adds a body explaining the change and footers
+	StyleFull Style = "full"
+)
+
+// ParseStyle validates a style name
+func ParseStyle(s string) (Style, error) {
+	switch Style(s) {
+	case StyleSubject, StyleFull:
+		return Style(s), nil
+	}
+	return "", fmt.Errorf("invalid message style: %s (expected \"subject\" or \"full\")", s)
+}
+
+// BreakingChange is the footer token that marks a breaking change
+const BreakingChange = "BREAKING CHANGE"
+
+// Footer is a git trailer such as "Refs: #123"
+type Footer struct {
+	Token string `json:"token"`
+	Value string `json:"value"`
+}
+
+// String renders the footer as a trailer line
+func (f Footer) String() string {
+	return f.Token + ": " + f.Value
+}
+
+// Message is a structured commit message
+type Message struct {
+	Type     string `json:"type"`
+	Scope    string `json:"scope,omitempty"`
+	Breaking bool   `json:"breaking,omitempty"`
+	// Gitmoji is the emoji or shortcode leading a gitmoji header
+	Gitmoji string `json:"gitmoji,omitempty"`
+	// Ticket is the issue key leading a ticket-prefixed header
+	Ticket string `json:"ticket,omitempty"`
+	// Subject is the description following the type and scope
+	Subject string `json:"subject"`
+	// Body holds paragraphs explaining the change
+	Body    []string `json:"body,omitempty"`
+	Footers []Footer `json:"footers,omitempty"`
+}
+
+// Header returns the first line in Conventional Commits format, e.g.
+// "feat(api)!: add pagination"
+func (m Message) Header() string {
+	return Conventional.Header(m)
+}
+
+// Footer returns the value of the first footer with token, ignoring case
+func (m Message) Footer(token string) (string, bool) {
+	for _, f := range m.Footers {
+		if strings.EqualFold(f.Token, token) {
+			return f.Value, true
+		}
+	}
+	return "", false
+}
+
+// Render formats the message for git in Conventional Commits format. The
+// header is shortened to SubjectLimit characters at a word boundary; in the
+// full style the body is wrapped at BodyWidth and followed by the footers.
+func (m Message) Render(style Style) string {
+	return Render(Conventional, m, style)
+}
+
+// Render formats m for git like Message.Render, writing the header in
+// convention c; a nil c is Conventional Commits
+func Render(c Convention, m Message, style Style) string {
+	if c == nil {
+		c = Conventional
+	}
+	header := c.Header(m)
+	if n := utf8.RuneCountInString(header); n > SubjectLimit {
+		m.Subject = shorten(m.Subject, utf8.RuneCountInString(m.Subject)-(n-SubjectLimit))
+		header = c.Header(m)
+	}
+	if style != StyleFull {
+		return header
+	}
+
+	var b strings.Builder
+	b.WriteString(header)
+	for _, paragraph := range m.Body {
+		if paragraph = strings.TrimSpace(paragraph); paragraph != "" {
+			b.WriteString("\n\n")
+			b.WriteString(Wrap(paragraph, BodyWidth))
+		}
+	}
+	if len(m.Footers) > 0 {
+		b.WriteString("\n")
+		for _, f := range m.Footers {
+			b.WriteString("\n")
+			b.WriteString(f.String())
+		}
+	}
+	return b.String()
+}
+
+// String renders the full message
+func (m Message) String() string {
+	return m.Render(StyleFull)
+}
+
+// shorten cuts s to at most limit characters, at a word boundary where
+// possible
+func shorten(s string, limit int) string {
+	if limit <= 0 {
+		return ""
+	}
+	if utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+	cut := string([]rune(s)[:limit])
+	if i := strings.LastIndex(cut, " "); i >= 0 && utf8.RuneCountInString(cut[:i]) > limit/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " ,;:-")
+}
+
+// Wrap wraps text at width columns. Lines that start a list item keep their
+// own line and wrapped continuation lines are indented to match; indented
+// lines, such as code, are left alone.
+func Wrap(text string, width int) string {
+	var out []string
+	var item []string
+	indent := ""
+
+	flush := func() {
+		if len(item) == 0 {
+			return
+		}
+		out = append(out, wrapWords(strings.Join(item, " "), width, indent)...)
+		item, indent = nil, ""
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			flush()
+			out = append(out, "")
+		case strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t"):
+			flush()
+			out = append(out, strings.TrimRight(line, " "))
+		case listMarker(trimmed) != "":
+			flush()
+			item = []string{trimmed}
+			indent = strings.Repeat(" ", len(listMarker(trimmed)))
+		default:
+			item = append(item, trimmed)
+		}
+	}
+	flush()
+	return strings.Join(out, "\n")
+}
+
+// listMarker returns the bullet or number starting a list item, including the
+// following space, or "" if line isn't one
+func listMarker(line string) string {
+	for _, bullet := range []string{"- ", "* ", "+ "} {
+		if strings.HasPrefix(line, bullet) {
+			return bullet
+		}
+	}
+	i := 0
+	for i < len(line) && line[i] >= '0' && line[i] <= '9' {
+		i++
+	}
+	if i > 0 && strings.HasPrefix(line[i:], ". ") {
+		return line[:i+2]
+	}
+	return ""
+}
+
+// wrapWords fills words into lines of at most width columns, indenting all
+// but the first line. Words longer than a line, such as URLs, aren't split.
+func wrapWords(text string, width int, indent string) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(text) {
+		switch {
+		case line == "":
+			line = word
+			if len(lines) > 0 {
+				line = indent + word
+			}
+		case utf8.RuneCountInString(line)+1+utf8.RuneCountInString(word) <= width:
+			line += " " + word
+		default:
+			lines = append(lines, line)
+			line = indent + word
+		}
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
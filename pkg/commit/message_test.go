@@ -0,0 +1,116 @@
+package commit
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseJSON(t *testing.T) {
+	text := "```json\n" + `{
+  "type": "feat",
+  "scope": "api",
+  "subject": "add cursor pagination to list endpoints",
+  "body": ["List endpoints returned every row, which timed out for large accounts.", "Clients pass the returned cursor to fetch the next page."],
+  "footers": [{"token": "BREAKING CHANGE", "value": "the offset parameter was removed"}, {"token": "Refs", "value": "#42"}]
+}` + "\n```"
+
+	m, err := Parse(text)
+	require.NoError(t, err)
+	assert.Equal(t, "feat", m.Type)
+	assert.Equal(t, "api", m.Scope)
+	assert.True(t, m.Breaking)
+	assert.Equal(t, "add cursor pagination to list endpoints", m.Subject)
+	assert.Len(t, m.Body, 2)
+	refs, ok := m.Footer("refs")
+	assert.True(t, ok)
+	assert.Equal(t, "#42", refs)
+}
+
+func TestParseJSONLooseShapes(t *testing.T) {
+	m, err := Parse(`Here you go: {"type": "fix", "subject": "fix(cli): handle EOF", "body": "First.\n\nSecond.", "footers": ["Refs: #7"]}`)
+	require.NoError(t, err)
+	assert.Equal(t, "fix", m.Type)
+	assert.Equal(t, "cli", m.Scope)
+	assert.Equal(t, "handle EOF", m.Subject)
+	assert.Equal(t, []string{"First.", "Second."}, m.Body)
+	assert.Equal(t, []Footer{{Token: "Refs", Value: "#7"}}, m.Footers)
+}
+
+func TestParseText(t *testing.T) {
+	m, err := Parse("refactor(git)!: split diff parsing\n\nMoves parsing into its own file.\n\n- keeps the API\n- adds tests\n\nBREAKING CHANGE: ParseDiff now returns pointers\nCo-authored-by: A <a@example.com>")
+	require.NoError(t, err)
+	assert.Equal(t, "refactor", m.Type)
+	assert.Equal(t, "git", m.Scope)
+	assert.True(t, m.Breaking)
+	assert.Equal(t, "split diff parsing", m.Subject)
+	assert.Equal(t, []string{"Moves parsing into its own file.", "- keeps the API\n- adds tests"}, m.Body)
+	assert.Equal(t, []Footer{
+		{Token: BreakingChange, Value: "ParseDiff now returns pointers"},
+		{Token: "Co-authored-by", Value: "A <a@example.com>"},
+	}, m.Footers)
+
+	m, err = Parse("Update readme")
+	require.NoError(t, err)
+	assert.Equal(t, Message{Subject: "Update readme"}, m)
+
+	_, err = Parse("  \n ")
+	assert.ErrorIs(t, err, ErrEmpty)
+}
+
+func TestRender(t *testing.T) {
+	m := Message{
+		Type:    "feat",
+		Scope:   "cli",
+		Subject: "let users pick between several generated commit message candidates interactively",
+		Body: []string{
+			"The chooser lists every candidate with a number so that users can accept one, edit one in their editor, or combine several of them.",
+			"- numbers accept a candidate directly without any further prompting at all\n- e<N> edits",
+		},
+		Footers: []Footer{{Token: "Refs", Value: "#14"}},
+	}
+
+	assert.Equal(t, "feat(cli): let users pick between several generated commit message", m.Render(StyleSubject))
+
+	full := m.Render(StyleFull)
+	for _, line := range strings.Split(full, "\n") {
+		assert.LessOrEqual(t, len(line), BodyWidth, line)
+	}
+	assert.Equal(t, `feat(cli): let users pick between several generated commit message
+
+The chooser lists every candidate with a number so that users can accept
+one, edit one in their editor, or combine several of them.
+
+- numbers accept a candidate directly without any further prompting at
+  all
+- e<N> edits
+
+Refs: #14`, full)
+}
+
+func TestRenderShortensByCharacter(t *testing.T) {
+	// 3 bytes per character, so a byte count would cut this early and split a character
+	m := Message{Type: "feat", Subject: strings.Repeat("添加分页", 20)}
+	header := m.Render(StyleSubject)
+	assert.True(t, utf8.ValidString(header))
+	assert.Equal(t, SubjectLimit, utf8.RuneCountInString(header))
+
+	m = Message{Type: "docs", Subject: "document the 🚀 release process for maintainers and contributors 🌍"}
+	assert.Equal(t, "docs: document the 🚀 release process for maintainers and contributors 🌍", m.Render(StyleSubject))
+}
+
+func TestWrapCountsCharacters(t *testing.T) {
+	// Each line fits in its width in characters, but not in bytes
+	assert.Equal(t, "添加 分页 和 排序 功能 以及\n过滤", Wrap("添加 分页 和 排序 功能 以及 过滤", 16))
+	assert.Equal(t, "üben über öfter\nändern", Wrap("üben über öfter ändern", 15))
+}
+
+func TestParseRenderRoundTrip(t *testing.T) {
+	text := "fix: handle EOF\n\nStop looping when stdin closes.\n\nRefs: #13"
+	m, err := Parse(text)
+	require.NoError(t, err)
+	assert.Equal(t, text, m.Render(StyleFull))
+}
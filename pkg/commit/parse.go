@@ -0,0 +1,264 @@
+package commit
+
+import (
+	"encoding/json"
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ErrEmpty is returned when there is no message to parse
+var ErrEmpty = errors.New("empty commit message")
+
+// headerPattern matches a Conventional Commits header
+var headerPattern = regexp.MustCompile(`^([A-Za-z]+)(?:\(([^()]*)\))?(!)?:\s*(.*)$`)
+
+// footerPattern matches a git trailer or a "BREAKING CHANGE" footer
+var footerPattern = regexp.MustCompile(`^(BREAKING[ -]CHANGE|[A-Za-z][A-Za-z0-9-]*)(: | #)(.+)$`)
+
+// Parse reads a Conventional Commits message from model output. JSON
+// objects in the shape of Message are accepted as well as plain text; for
+// plain text the first line is the header, and a final paragraph made up of
+// trailers is read as the footers.
+func Parse(text string) (Message, error) {
+	return ParseWith(Conventional, text)
+}
+
+// ParseWith reads a commit message from model output, like Parse, reading
+// the header in convention c
+func ParseWith(c Convention, text string) (Message, error) {
+	text = strings.TrimSpace(stripFences(text))
+	if text == "" {
+		return Message{}, ErrEmpty
+	}
+
+	if m, ok := parseJSON(c, text); ok {
+		return m, nil
+	}
+	return parseText(c, text), nil
+}
+
+// stripFences removes a surrounding markdown code fence
+func stripFences(text string) string {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, "```") {
+		return text
+	}
+	lines := strings.Split(text, "\n")
+	lines = lines[1:]
+	if n := len(lines); n > 0 && strings.HasPrefix(strings.TrimSpace(lines[n-1]), "```") {
+		lines = lines[:n-1]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// jsonMessage accepts the looser shapes models produce for a Message
+type jsonMessage struct {
+	Type     string          `json:"type"`
+	Scope    string          `json:"scope"`
+	Breaking bool            `json:"breaking"`
+	Gitmoji  string          `json:"gitmoji"`
+	Emoji    string          `json:"emoji"`
+	Ticket   string          `json:"ticket"`
+	Subject  string          `json:"subject"`
+	Body     json.RawMessage `json:"body"`
+	Footers  json.RawMessage `json:"footers"`
+}
+
+func parseJSON(c Convention, text string) (Message, bool) {
+	start, end := strings.Index(text, "{"), strings.LastIndex(text, "}")
+	if start < 0 || end < start {
+		return Message{}, false
+	}
+
+	var raw jsonMessage
+	if err := json.Unmarshal([]byte(text[start:end+1]), &raw); err != nil || raw.Subject == "" {
+		return Message{}, false
+	}
+
+	m := Message{
+		Type:     strings.TrimSpace(raw.Type),
+		Scope:    strings.TrimSpace(raw.Scope),
+		Breaking: raw.Breaking,
+		Gitmoji:  strings.TrimSpace(raw.Gitmoji),
+		Ticket:   strings.TrimSpace(raw.Ticket),
+		Subject:  strings.TrimSpace(raw.Subject),
+	}
+	if m.Gitmoji == "" {
+		m.Gitmoji = strings.TrimSpace(raw.Emoji)
+	}
+
+	// The subject sometimes repeats the whole header
+	if h, ok := c.ParseHeader(m.Subject); ok && (m.Type == "" || h.Type == "" || strings.EqualFold(h.Type, m.Type)) {
+		m.Subject = h.Subject
+		m.Breaking = m.Breaking || h.Breaking
+		if h.Type != "" {
+			m.Type = h.Type
+		}
+		if h.Scope != "" {
+			m.Scope = h.Scope
+		}
+		if h.Gitmoji != "" {
+			m.Gitmoji = h.Gitmoji
+		}
+		if h.Ticket != "" {
+			m.Ticket = h.Ticket
+		}
+	}
+
+	var body string
+	var paragraphs []string
+	if json.Unmarshal(raw.Body, &body) == nil {
+		paragraphs = splitParagraphs(body)
+	} else {
+		_ = json.Unmarshal(raw.Body, &paragraphs)
+	}
+	for _, p := range paragraphs {
+		if p = strings.TrimSpace(p); p != "" {
+			m.Body = append(m.Body, p)
+		}
+	}
+
+	m.Footers = parseJSONFooters(raw.Footers)
+	m.normalizeBreaking()
+	return m, true
+}
+
+// parseJSONFooters accepts a list of {token, value} objects, a list of
+// "Token: value" strings, or an object mapping tokens to values
+func parseJSONFooters(raw json.RawMessage) []Footer {
+	var footers []Footer
+	if json.Unmarshal(raw, &footers) == nil {
+		var out []Footer
+		for _, f := range footers {
+			if f.Token != "" && f.Value != "" {
+				out = append(out, Footer{Token: strings.TrimSpace(f.Token), Value: strings.TrimSpace(f.Value)})
+			}
+		}
+		return out
+	}
+
+	var lines []string
+	if json.Unmarshal(raw, &lines) == nil {
+		var out []Footer
+		for _, line := range lines {
+			if f, ok := parseFooter(line); ok {
+				out = append(out, f)
+			}
+		}
+		return out
+	}
+
+	var byToken map[string]string
+	if json.Unmarshal(raw, &byToken) == nil {
+		tokens := make([]string, 0, len(byToken))
+		for token := range byToken {
+			tokens = append(tokens, token)
+		}
+		sort.Strings(tokens)
+
+		var out []Footer
+		for _, token := range tokens {
+			if value := byToken[token]; value != "" {
+				out = append(out, Footer{Token: token, Value: value})
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func parseText(c Convention, text string) Message {
+	paragraphs := splitParagraphs(text)
+
+	lines := strings.SplitN(paragraphs[0], "\n", 2)
+	header := strings.TrimSpace(lines[0])
+	m, ok := c.ParseHeader(header)
+	if !ok {
+		m = Message{Subject: header}
+	}
+
+	// Text directly under the header, without a blank line, is still body
+	if len(lines) > 1 {
+		paragraphs[0] = lines[1]
+	} else {
+		paragraphs = paragraphs[1:]
+	}
+
+	if n := len(paragraphs); n > 0 {
+		if footers, ok := parseFooters(paragraphs[n-1]); ok {
+			m.Footers = footers
+			paragraphs = paragraphs[:n-1]
+		}
+	}
+	if len(paragraphs) > 0 {
+		m.Body = paragraphs
+	}
+	m.normalizeBreaking()
+	return m
+}
+
+// splitParagraphs splits text at blank lines
+func splitParagraphs(text string) []string {
+	var paragraphs []string
+	var current []string
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		if strings.TrimSpace(line) == "" {
+			if len(current) > 0 {
+				paragraphs = append(paragraphs, strings.Join(current, "\n"))
+				current = nil
+			}
+			continue
+		}
+		current = append(current, strings.TrimRight(line, " \t"))
+	}
+	if len(current) > 0 {
+		paragraphs = append(paragraphs, strings.Join(current, "\n"))
+	}
+	if len(paragraphs) == 0 {
+		paragraphs = []string{""}
+	}
+	return paragraphs
+}
+
+// parseFooters reads a paragraph in which every line starts a footer or
+// continues the previous one
+func parseFooters(paragraph string) ([]Footer, bool) {
+	var footers []Footer
+	for _, line := range strings.Split(paragraph, "\n") {
+		if f, ok := parseFooter(line); ok {
+			footers = append(footers, f)
+			continue
+		}
+		if len(footers) == 0 || !strings.HasPrefix(line, " ") {
+			return nil, false
+		}
+		footers[len(footers)-1].Value += " " + strings.TrimSpace(line)
+	}
+	return footers, len(footers) > 0
+}
+
+func parseFooter(line string) (Footer, bool) {
+	f := footerPattern.FindStringSubmatch(strings.TrimSpace(line))
+	if f == nil {
+		return Footer{}, false
+	}
+	token := f[1]
+	if strings.EqualFold(strings.ReplaceAll(token, "-", " "), BreakingChange) {
+		token = BreakingChange
+	}
+	value := strings.TrimSpace(f[3])
+	if f[2] == " #" {
+		// "Closes #12" style references keep their hash
+		value = "#" + value
+	}
+	return Footer{Token: token, Value: value}, true
+}
+
+// normalizeBreaking marks messages with a BREAKING CHANGE footer as breaking
+func (m *Message) normalizeBreaking() {
+	if _, ok := m.Footer(BreakingChange); ok {
+		m.Breaking = true
+	}
+}
@@ -0,0 +1,156 @@
+package commit
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// TicketPrefixed is a header led by an issue key in brackets, e.g.
+// "[PROJ-123] Add pagination"
+var TicketPrefixed Convention = ticketPrefixed{}
+
+type ticketPrefixed struct{}
+
+// ticketHeader matches a header led by an issue key, in brackets or
+// followed by a colon
+var ticketHeader = regexp.MustCompile(`^(?:\[([A-Za-z][A-Za-z0-9]*-[0-9]+)\]|([A-Za-z][A-Za-z0-9]*-[0-9]+):?)\s+(.*)$`)
+
+func (ticketPrefixed) Name() string         { return "ticket" }
+func (ticketPrefixed) Title() string        { return "the team's ticket-prefixed format" }
+func (ticketPrefixed) DefaultTypes() []Type { return nil }
+func (ticketPrefixed) Scoped() bool         { return false }
+
+func (ticketPrefixed) Instructions(types []Type, scopes []string) string {
+	return "The format should be: [<ticket>] <description>\n\n" +
+		"Where <ticket> is the key of the issue the change belongs to, such as PROJ-123, usually found in the branch name."
+}
+
+func (ticketPrefixed) Schema() string {
+	return `"ticket": "<ticket>", "subject": "<description>"`
+}
+
+func (ticketPrefixed) ParseHeader(header string) (Message, bool) {
+	h := ticketHeader.FindStringSubmatch(header)
+	if h == nil {
+		return Message{}, false
+	}
+	return Message{Ticket: h[1] + h[2], Subject: h[3]}, true
+}
+
+// Header writes e.g. "[PROJ-123] Add pagination"
+func (ticketPrefixed) Header(m Message) string {
+	if m.Ticket == "" {
+		return m.Subject
+	}
+	return "[" + m.Ticket + "] " + m.Subject
+}
+
+func (ticketPrefixed) Validate(r Rules, m Message) []Violation {
+	if m.Ticket == "" {
+		return []Violation{violation("ticket-empty", 1, "the header must start with a ticket reference, e.g. \"[PROJ-123] \"")}
+	}
+	return nil
+}
+
+// Fix upper-cases the issue key, which is how trackers write them, and uses
+// the rules' ticket when the model left it out
+func (ticketPrefixed) Fix(r Rules, m Message) Message {
+	m.Ticket = strings.ToUpper(strings.Trim(strings.TrimSpace(m.Ticket), "[]"))
+	if m.Ticket == "" {
+		m.Ticket = r.Ticket
+	}
+	return m
+}
+
+// TicketPlacement is where AddTicket puts a ticket reference
+type TicketPlacement string
+
+const (
+	// TicketFooter adds a trailer, e.g. "Refs: PROJ-123"
+	TicketFooter TicketPlacement = "footer"
+	// TicketPrefix starts the description with the reference
+	TicketPrefix TicketPlacement = "prefix"
+	// TicketScope makes the reference the scope of the header
+	TicketScope TicketPlacement = "scope"
+)
+
+// ParseTicketPlacement validates a placement name
+func ParseTicketPlacement(s string) (TicketPlacement, error) {
+	switch p := TicketPlacement(s); p {
+	case TicketFooter, TicketPrefix, TicketScope:
+		return p, nil
+	}
+	return "", fmt.Errorf("invalid ticket placement: %s (expected \"footer\", \"prefix\" or \"scope\")", s)
+}
+
+// EffectiveTicketPlacement is where AddTicket should put Ticket:
+// TicketPlacement, or the footer when that is the scope and Scopes doesn't
+// allow the ticket, as a commitlint scope-enum usually won't
+func (r Rules) EffectiveTicketPlacement() TicketPlacement {
+	if r.TicketPlacement == TicketScope && len(r.Scopes) > 0 && !contains(r.Scopes, r.Ticket) {
+		return TicketFooter
+	}
+	return r.TicketPlacement
+}
+
+// HasTicket reports whether message mentions ticket, ignoring case and the
+// comment lines git will remove, which start with commentChar or, when it's
+// empty, DefaultCommentChar. "PROJ-12" isn't found in "PROJ-123".
+func HasTicket(message, ticket, commentChar string) bool {
+	if ticket == "" {
+		return false
+	}
+	pattern := regexp.MustCompile(`(?i)(?:^|[^A-Za-z0-9])` + regexp.QuoteMeta(ticket) + `(?:$|[^A-Za-z0-9])`)
+	return pattern.MatchString(Strip(message, commentChar))
+}
+
+// AddTicket adds a reference to ticket to message, written in convention
+// c, unless it already mentions it outside comment lines starting with
+// commentChar. The footer is named token, e.g. "Refs".
+// The ticket-prefixed convention always carries the ticket in its header;
+// other placements a header can't take, such as a scope when there already
+// is one, fall back to the footer.
+func AddTicket(c Convention, message, ticket string, placement TicketPlacement, token, commentChar string) string {
+	if ticket == "" || HasTicket(message, ticket, commentChar) {
+		return message
+	}
+	if c == nil {
+		c = Conventional
+	}
+
+	header, rest, _ := strings.Cut(message, "\n")
+	if rest != "" {
+		rest = "\n" + rest
+	}
+	h, ok := c.ParseHeader(header)
+	switch {
+	case c.Name() == TicketPrefixed.Name():
+		if !ok {
+			h = Message{Subject: header}
+		}
+		h.Ticket = ticket
+		return c.Header(h) + rest
+	case placement == TicketScope && ok && c.Scoped() && h.Scope == "":
+		h.Scope = ticket
+		return c.Header(h) + rest
+	case placement == TicketPrefix && ok:
+		h.Subject = ticket + " " + h.Subject
+		return c.Header(h) + rest
+	case placement == TicketPrefix:
+		return ticket + " " + message
+	}
+	return addFooter(message, Footer{Token: token, Value: ticket})
+}
+
+// addFooter appends f to the footers ending message, or starts them
+func addFooter(message string, f Footer) string {
+	message = strings.TrimRight(message, "\n")
+	paragraphs := splitParagraphs(message)
+	if n := len(paragraphs); n > 1 {
+		if _, ok := parseFooters(paragraphs[n-1]); ok {
+			return message + "\n" + f.String()
+		}
+	}
+	return message + "\n\n" + f.String()
+}